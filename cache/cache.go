@@ -0,0 +1,18 @@
+// Package cache is the shared second-level cache behind the DataLoaders.
+// Unlike the per-request loader caches it lives for the whole process (or,
+// with a networked Store, is shared by all instances).
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store holds encoded entities under string keys. Values are opaque bytes
+// so that a Redis-compatible store (GET, SET with EX, DEL) can implement it.
+// Errors are the store's to log; a failing cache must behave like a miss.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	Delete(ctx context.Context, keys ...string)
+}
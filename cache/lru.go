@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// LRU is an in-process Store keeping at most size entries, each until its
+// TTL expires.
+type LRU struct {
+	entries *lru.Cache[string, entry]
+	now     func() time.Time
+}
+
+type entry struct {
+	value   []byte
+	expires time.Time
+}
+
+var _ Store = (*LRU)(nil)
+
+func NewLRU(size int) (*LRU, error) {
+	entries, err := lru.New[string, entry](size)
+	if err != nil {
+		return nil, err
+	}
+	return &LRU{entries: entries, now: time.Now}, nil
+}
+
+func (c *LRU) Get(_ context.Context, key string) ([]byte, bool) {
+	e, ok := c.entries.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if c.now().After(e.expires) {
+		c.entries.Remove(key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *LRU) Set(_ context.Context, key string, value []byte, ttl time.Duration) {
+	c.entries.Add(key, entry{value: value, expires: c.now().Add(ttl)})
+}
+
+func (c *LRU) Delete(_ context.Context, keys ...string) {
+	for _, key := range keys {
+		c.entries.Remove(key)
+	}
+}
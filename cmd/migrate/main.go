@@ -1,27 +1,48 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"text/tabwriter"
+	"time"
 
+	"graphql-backend/config"
 	"graphql-backend/database"
 	"graphql-backend/migrations"
 
 	_ "github.com/go-sql-driver/mysql"
+	"gopkg.in/yaml.v3"
 )
 
 func main() {
 	var (
-		action     = flag.String("action", "up", "Migration action: up, down, status, create")
+		action     = flag.String("action", "up", "Migration action: up, down, goto, redo, status, create, dump, diff")
 		steps      = flag.Int("steps", 1, "Number of steps for down migration")
+		version    = flag.Int64("version", -1, "Target version for goto action (0 rolls back everything)")
 		name       = flag.String("name", "", "Name for new migration (required for create action)")
-		migrateDir = flag.String("dir", "migrations", "Directory containing migration files")
+		migrateDir = flag.String("dir", "", "Directory containing migration files (default: migrations.dir from config)")
+		configPath = flag.String("config", "", "Path to a YAML or TOML config file (default: $CONFIG_FILE)")
+		dryRun     = flag.Bool("dry-run", false, "Print the SQL that would be executed without touching the database")
+		format     = flag.String("format", "table", "Output format for status action: table, json, yaml")
+		snapshot   = flag.String("snapshot", "", "Schema snapshot file for dump and diff (default: <dir>/schema.snapshot.json, - for stdout)")
+		from       = flag.String("from", "", "Schema to diff from: a snapshot file or \"db\" (default: the snapshot)")
+		to         = flag.String("to", "db", "Schema to diff to: a snapshot file or \"db\"")
 	)
 	flag.Parse()
 
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	if *migrateDir == "" {
+		*migrateDir = cfg.Migrations.Dir
+	}
+
 	// Get absolute path for migrations directory
 	backendDir, err := os.Getwd()
 	if err != nil {
@@ -29,25 +50,89 @@ func main() {
 	}
 	migrationsDir := filepath.Join(backendDir, *migrateDir)
 
+	snapshotPath := *snapshot
+	if snapshotPath == "" {
+		snapshotPath = filepath.Join(migrationsDir, "schema.snapshot.json")
+	}
+
 	switch *action {
 	case "create":
 		if *name == "" {
 			log.Fatal("Migration name is required for create action. Use -name flag")
 		}
-		if err := migrations.CreateMigration(migrationsDir, *name); err != nil {
+		if *dryRun {
+			fmt.Printf("Would create migration %q in %s\n", *name, migrationsDir)
+			return
+		}
+		if _, err := migrations.CreateMigration(migrationsDir, *name, "", ""); err != nil {
+			log.Fatal("Failed to create migration:", err)
+		}
+		return
+
+	case "diff":
+		fromSource := *from
+		if fromSource == "" {
+			fromSource = snapshotPath
+		}
+
+		var migrator *migrations.Migrator
+		if fromSource == "db" || *to == "db" {
+			db, err := database.NewDB(cfg.Database)
+			if err != nil {
+				log.Fatal("Failed to connect to database:", err)
+			}
+			defer db.Close()
+			migrator = migrations.NewMigrator(db.DB)
+		}
+
+		fromSchema, err := loadSchema(migrator, fromSource)
+		if err != nil {
+			log.Fatal("Failed to load schema:", err)
+		}
+		toSchema, err := loadSchema(migrator, *to)
+		if err != nil {
+			log.Fatal("Failed to load schema:", err)
+		}
+
+		up := migrations.DiffSchemas(fromSchema, toSchema)
+		if len(up) == 0 {
+			log.Println("No schema changes")
+			return
+		}
+		upSQL := migrations.FormatStatements(up)
+		downSQL := migrations.FormatStatements(migrations.DiffSchemas(toSchema, fromSchema))
+
+		if *dryRun {
+			fmt.Printf("-- +migrate Up\n%s\n\n-- +migrate Down\n%s\n", upSQL, downSQL)
+			return
+		}
+
+		migrationName := *name
+		if migrationName == "" {
+			migrationName = "schema_diff"
+		}
+		if _, err := migrations.CreateMigration(migrationsDir, migrationName, upSQL, downSQL); err != nil {
 			log.Fatal("Failed to create migration:", err)
 		}
 		return
 
-	case "up", "down", "status":
+	case "up", "down", "goto", "redo", "status", "dump":
+		if *action == "status" && *format != "table" && *format != "json" && *format != "yaml" {
+			log.Fatalf("Unknown format: %s (available: table, json, yaml)", *format)
+		}
+		if *action == "goto" && *version < 0 {
+			log.Fatal("Target version is required for goto action. Use -version flag")
+		}
+
 		// Connect to database for other actions
-		db, err := database.NewDB()
+		db, err := database.NewDB(cfg.Database)
 		if err != nil {
 			log.Fatal("Failed to connect to database:", err)
 		}
 		defer db.Close()
 
 		migrator := migrations.NewMigrator(db.DB)
+		migrator.SetDryRun(*dryRun)
 
 		switch *action {
 		case "up":
@@ -60,16 +145,86 @@ func main() {
 				log.Fatal("Failed to rollback migrations:", err)
 			}
 
+		case "goto":
+			if err := migrator.Goto(migrationsDir, *version); err != nil {
+				log.Fatal("Failed to migrate to version:", err)
+			}
+
+		case "redo":
+			if err := migrator.Redo(migrationsDir); err != nil {
+				log.Fatal("Failed to redo migration:", err)
+			}
+
 		case "status":
-			if err := migrator.Status(migrationsDir); err != nil {
+			statuses, err := migrator.Status(migrationsDir)
+			if err != nil {
 				log.Fatal("Failed to get migration status:", err)
 			}
+			if err := printStatus(os.Stdout, statuses, *format); err != nil {
+				log.Fatal("Failed to print migration status:", err)
+			}
+
+		case "dump":
+			schema, err := migrator.Dump()
+			if err != nil {
+				log.Fatal("Failed to dump schema:", err)
+			}
+			if *dryRun || snapshotPath == "-" {
+				if err := migrations.EncodeSchema(os.Stdout, schema); err != nil {
+					log.Fatal("Failed to print schema:", err)
+				}
+				return
+			}
+			if err := migrations.WriteSchema(snapshotPath, schema); err != nil {
+				log.Fatal("Failed to write schema:", err)
+			}
+			log.Printf("Schema snapshot written: %s", snapshotPath)
 		}
 
 	default:
 		fmt.Printf("Unknown action: %s\n", *action)
-		fmt.Println("Available actions: up, down, status, create")
+		fmt.Println("Available actions: up, down, goto, redo, status, create, dump, diff")
 		flag.Usage()
 		os.Exit(1)
 	}
 }
+
+func printStatus(w io.Writer, statuses []migrations.MigrationStatus, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(statuses)
+
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		defer enc.Close()
+		return enc.Encode(statuses)
+	}
+
+	fmt.Fprintln(w, "Migration Status:")
+	fmt.Fprintln(w, "================")
+
+	if len(statuses) == 0 {
+		fmt.Fprintln(w, "No migrations found")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range statuses {
+		appliedAt := "-"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%dms\n", s.Version, s.State, s.Kind, s.Name, appliedAt, s.DurationMs)
+	}
+	return tw.Flush()
+}
+
+// loadSchema reads a snapshot file, or introspects the database when source is "db".
+func loadSchema(migrator *migrations.Migrator, source string) (*migrations.Schema, error) {
+	if source == "db" {
+		return migrator.Dump()
+	}
+	return migrations.LoadSchema(source)
+}
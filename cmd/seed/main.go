@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"graphql-backend/config"
+	"graphql-backend/database"
+	"graphql-backend/seeds"
+
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func main() {
+	var (
+		configPath = flag.String("config", "", "Path to a YAML or TOML config file (default: $CONFIG_FILE)")
+		env        = flag.String("env", "", "Environment whose seed sets are loaded (default: env from config)")
+		set        = flag.String("set", "", "Load only the named seed set")
+		list       = flag.Bool("list", false, "List seed sets and their environments")
+		fakeUsers  = flag.Int("fake-users", 0, "Generate N fake users instead of loading seed sets")
+		fakePosts  = flag.Int("fake-posts", 0, "Number of posts per fake user")
+	)
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	if *env == "" {
+		*env = cfg.Env
+	}
+
+	if *list {
+		for _, s := range seeds.Sets() {
+			fmt.Printf("%s\t%d users\t%v\n", s.Name, len(s.Users), s.Environments)
+		}
+		return
+	}
+
+	var toRun []seeds.Set
+	if *fakeUsers > 0 {
+		if *env == "production" {
+			log.Fatal("Refusing to generate fake data in production")
+		}
+		toRun = []seeds.Set{seeds.Fake(*fakeUsers, *fakePosts)}
+	} else {
+		sets, err := seeds.ForEnvironment(*env, *set)
+		if err != nil {
+			log.Fatal("Failed to select seed sets:", err)
+		}
+		if len(sets) == 0 {
+			log.Printf("No seed sets for environment %s", *env)
+			return
+		}
+		toRun = sets
+	}
+
+	db, err := database.NewDB(cfg.Database)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	seeder := seeds.NewSeeder(db.DB)
+	for _, s := range toRun {
+		if err := seeder.Run(context.Background(), s); err != nil {
+			log.Fatal("Failed to seed database:", err)
+		}
+	}
+}
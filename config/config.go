@@ -0,0 +1,748 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the complete service configuration. Values are resolved in the
+// order defaults → config file → environment variables, so an env var always
+// wins over the file.
+type Config struct {
+	Env        string           `yaml:"env" toml:"env" env:"APP_ENV"`
+	Server     ServerConfig     `yaml:"server" toml:"server"`
+	Logging    LoggingConfig    `yaml:"logging" toml:"logging"`
+	Tracing    TracingConfig    `yaml:"tracing" toml:"tracing"`
+	Metrics    MetricsConfig    `yaml:"metrics" toml:"metrics"`
+	Database   DatabaseConfig   `yaml:"database" toml:"database"`
+	CORS       CORSConfig       `yaml:"cors" toml:"cors"`
+	GraphQL    GraphQLConfig    `yaml:"graphql" toml:"graphql"`
+	DataLoader DataLoaderConfig `yaml:"dataloader" toml:"dataloader"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit" toml:"rate_limit"`
+	Comments   CommentsConfig   `yaml:"comments" toml:"comments"`
+	Migrations MigrationsConfig `yaml:"migrations" toml:"migrations"`
+	Health     HealthConfig     `yaml:"health" toml:"health"`
+}
+
+type ServerConfig struct {
+	Port string `yaml:"port" toml:"port" env:"PORT"`
+
+	ReadTimeout       Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	WriteTimeout      Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// ShutdownTimeout bounds draining in-flight requests and websocket
+	// subscriptions after SIGTERM; keep it below the pod's grace period.
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port     string `yaml:"port" toml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME"`
+
+	ConnectRetries int      `yaml:"connect_retries" toml:"connect_retries" env:"DB_CONNECT_RETRIES"`
+	RetryDelay     Duration `yaml:"retry_delay" toml:"retry_delay" env:"DB_RETRY_DELAY"`
+
+	// Pool sizing. MaxOpenConns 0 means unlimited.
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+
+	DialTimeout  Duration `yaml:"dial_timeout" toml:"dial_timeout" env:"DB_DIAL_TIMEOUT"`
+	ReadTimeout  Duration `yaml:"read_timeout" toml:"read_timeout" env:"DB_READ_TIMEOUT"`
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout" env:"DB_WRITE_TIMEOUT"`
+
+	// Timezone is the IANA name used to interpret DATETIME/TIMESTAMP values,
+	// or "Local" for the process timezone.
+	Timezone string `yaml:"timezone" toml:"timezone" env:"DB_TIMEZONE"`
+
+	TLS DatabaseTLSConfig `yaml:"tls" toml:"tls"`
+
+	Replicas ReplicasConfig `yaml:"replicas" toml:"replicas"`
+
+	// StatsInterval is how often pool statistics are published; 0 disables it.
+	StatsInterval Duration `yaml:"stats_interval" toml:"stats_interval" env:"DB_STATS_INTERVAL"`
+}
+
+// ReplicasConfig lists read replicas. They share credentials, database name,
+// pool and TLS settings with the primary.
+type ReplicasConfig struct {
+	// Hosts are "host" or "host:port" entries; the port defaults to the
+	// primary's.
+	Hosts []string `yaml:"hosts" toml:"hosts" env:"DB_REPLICA_HOSTS"`
+	// MaxLag is the replication delay above which a replica stops serving
+	// reads.
+	MaxLag              Duration `yaml:"max_lag" toml:"max_lag" env:"DB_REPLICA_MAX_LAG"`
+	HealthCheckInterval Duration `yaml:"health_check_interval" toml:"health_check_interval" env:"DB_REPLICA_HEALTH_CHECK_INTERVAL"`
+}
+
+type DatabaseTLSConfig struct {
+	// Mode is one of "disabled", "preferred", "skip-verify", "required" or
+	// "custom". "custom" uses the CA/cert/key files below.
+	Mode       string `yaml:"mode" toml:"mode" env:"DB_TLS_MODE"`
+	CAFile     string `yaml:"ca_file" toml:"ca_file" env:"DB_TLS_CA_FILE"`
+	CertFile   string `yaml:"cert_file" toml:"cert_file" env:"DB_TLS_CERT_FILE"`
+	KeyFile    string `yaml:"key_file" toml:"key_file" env:"DB_TLS_KEY_FILE"`
+	ServerName string `yaml:"server_name" toml:"server_name" env:"DB_TLS_SERVER_NAME"`
+}
+
+type LoggingConfig struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+	// SlowOperationThreshold logs operations taking at least this long as
+	// warnings; 0 disables it.
+	SlowOperationThreshold Duration `yaml:"slow_operation_threshold" toml:"slow_operation_threshold" env:"LOG_SLOW_OPERATION_THRESHOLD"`
+	// RedactVariables are variable and input field names (case-insensitive)
+	// whose values are never logged.
+	RedactVariables []string `yaml:"redact_variables" toml:"redact_variables" env:"LOG_REDACT_VARIABLES"`
+}
+
+type TracingConfig struct {
+	// Exporter is "none", "stdout" or "otlp" (OTLP over HTTP).
+	Exporter     string `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER"`
+	OTLPEndpoint string `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	ServiceName  string `yaml:"service_name" toml:"service_name" env:"TRACING_SERVICE_NAME"`
+	// SampleRatio is the share of requests traced; ResolverSampleRatio the
+	// share of resolver calls within a traced request that get a span.
+	SampleRatio         float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+	ResolverSampleRatio float64 `yaml:"resolver_sample_ratio" toml:"resolver_sample_ratio" env:"TRACING_RESOLVER_SAMPLE_RATIO"`
+}
+
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled" env:"METRICS_ENABLED"`
+	Path    string `yaml:"path" toml:"path" env:"METRICS_PATH"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string `yaml:"allowed_methods" toml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string `yaml:"allowed_headers" toml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+}
+
+type GraphQLConfig struct {
+	// MaxDepth and MaxComplexity reject operations before execution; 0
+	// disables the check.
+	MaxDepth      int `yaml:"max_depth" toml:"max_depth" env:"GRAPHQL_MAX_DEPTH"`
+	MaxComplexity int `yaml:"max_complexity" toml:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY"`
+	// DefaultListSize is the assumed length of list fields that have neither
+	// a pagination argument nor a @cost(listSize:) override.
+	DefaultListSize int `yaml:"default_list_size" toml:"default_list_size" env:"GRAPHQL_DEFAULT_LIST_SIZE"`
+	// MaxPageSize is the largest first/last/limit value the complexity
+	// calculation takes at face value; larger ones count as MaxPageSize.
+	MaxPageSize int `yaml:"max_page_size" toml:"max_page_size" env:"GRAPHQL_MAX_PAGE_SIZE"`
+	// MaxBatchSize is the most operations a batched request (a JSON array)
+	// may contain; 0 disables batching.
+	MaxBatchSize int `yaml:"max_batch_size" toml:"max_batch_size" env:"GRAPHQL_MAX_BATCH_SIZE"`
+
+	PersistedQueries PersistedQueriesConfig `yaml:"persisted_queries" toml:"persisted_queries"`
+}
+
+type PersistedQueriesConfig struct {
+	// Cache stores automatic persisted queries: "memory" (per-instance LRU),
+	// "mysql" (persisted_queries table shared by all instances) or "none".
+	Cache     string `yaml:"cache" toml:"cache" env:"APQ_CACHE"`
+	CacheSize int    `yaml:"cache_size" toml:"cache_size" env:"APQ_CACHE_SIZE"`
+	// MaxStored caps the rows of the "mysql" cache. Any client can register
+	// a query, so the oldest registrations are evicted beyond it.
+	MaxStored int `yaml:"max_stored" toml:"max_stored" env:"APQ_MAX_STORED"`
+	// Manifest is a JSON file of queries registered when the clients were
+	// built. With AllowlistOnly, which is implied in production, only those
+	// queries are executable.
+	Manifest      string `yaml:"manifest" toml:"manifest" env:"PERSISTED_QUERIES_MANIFEST"`
+	AllowlistOnly bool   `yaml:"allowlist_only" toml:"allowlist_only" env:"PERSISTED_QUERIES_ALLOWLIST_ONLY"`
+}
+
+type DataLoaderConfig struct {
+	// Wait is how long a loader collects keys before dispatching a batch.
+	Wait Duration `yaml:"wait" toml:"wait" env:"DATALOADER_WAIT"`
+	// MaxBatch caps the keys per batch; 0 means unlimited.
+	MaxBatch int `yaml:"max_batch" toml:"max_batch" env:"DATALOADER_MAX_BATCH"`
+	// Cache is the per-request cache: "memory" (unbounded), "lru" (at most
+	// CacheSize entries) or "none".
+	Cache     string `yaml:"cache" toml:"cache" env:"DATALOADER_CACHE"`
+	CacheSize int    `yaml:"cache_size" toml:"cache_size" env:"DATALOADER_CACHE_SIZE"`
+	// Loaders overrides the settings above for single loaders, keyed by
+	// loader name. They can only be set in the config file.
+	Loaders map[string]LoaderConfig `yaml:"loaders" toml:"loaders"`
+	// Shared is the cross-request cache behind all loaders.
+	Shared SharedCacheConfig `yaml:"shared" toml:"shared"`
+}
+
+type SharedCacheConfig struct {
+	// Backend is "none" or "memory" (in-process LRU of Size entries).
+	Backend string `yaml:"backend" toml:"backend" env:"SHARED_CACHE_BACKEND"`
+	Size    int    `yaml:"size" toml:"size" env:"SHARED_CACHE_SIZE"`
+	// TTL bounds how stale an entry can get when it is changed by something
+	// other than this service's repositories.
+	TTL Duration `yaml:"ttl" toml:"ttl" env:"SHARED_CACHE_TTL"`
+}
+
+// loaderNames are the loaders built by models/loaders, i.e. the keys
+// DataLoaderConfig.Loaders accepts. Add new loaders here.
+var loaderNames = []string{"user_by_id", "posts_by_user_id", "comments_by_post_id", "comment_replies", "tags_by_post_id"}
+
+// LoaderConfig holds per-loader overrides; unset fields fall back to the
+// DataLoaderConfig defaults.
+type LoaderConfig struct {
+	Wait      *Duration `yaml:"wait,omitempty" toml:"wait,omitempty"`
+	MaxBatch  *int      `yaml:"max_batch,omitempty" toml:"max_batch,omitempty"`
+	Cache     string    `yaml:"cache,omitempty" toml:"cache,omitempty"`
+	CacheSize int       `yaml:"cache_size,omitempty" toml:"cache_size,omitempty"`
+}
+
+// Loader returns the effective settings of the named loader.
+func (c DataLoaderConfig) Loader(name string) DataLoaderConfig {
+	effective := c
+	effective.Loaders = nil
+
+	override, ok := c.Loaders[name]
+	if !ok {
+		return effective
+	}
+	if override.Wait != nil {
+		effective.Wait = *override.Wait
+	}
+	if override.MaxBatch != nil {
+		effective.MaxBatch = *override.MaxBatch
+	}
+	if override.Cache != "" {
+		effective.Cache = override.Cache
+	}
+	if override.CacheSize != 0 {
+		effective.CacheSize = override.CacheSize
+	}
+	return effective
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	// Each client has a bucket of Burst tokens refilled at Rate tokens per
+	// second; an operation costs its complexity.
+	Rate  int `yaml:"rate" toml:"rate" env:"RATE_LIMIT_RATE"`
+	Burst int `yaml:"burst" toml:"burst" env:"RATE_LIMIT_BURST"`
+	// TrustForwardedFor keys anonymous clients by X-Forwarded-For; only
+	// enable it behind a proxy that sets the header.
+	TrustForwardedFor bool `yaml:"trust_forwarded_for" toml:"trust_forwarded_for" env:"RATE_LIMIT_TRUST_FORWARDED_FOR"`
+	// Operations adds a limit for specific operation names on top of the
+	// default one. They can only be set in the config file.
+	Operations []OperationRateLimit `yaml:"operations" toml:"operations"`
+}
+
+type OperationRateLimit struct {
+	Name  string `yaml:"name" toml:"name"`
+	Rate  int    `yaml:"rate" toml:"rate"`
+	Burst int    `yaml:"burst" toml:"burst"`
+}
+
+type CommentsConfig struct {
+	// MaxDepth is how many levels of replies a top-level comment can have; 0
+	// disables replies. MySQL cascades deletes at most 15 levels deep.
+	MaxDepth int `yaml:"max_depth" toml:"max_depth" env:"COMMENTS_MAX_DEPTH"`
+	// MaxPageSize caps the first argument of comment connections.
+	MaxPageSize int `yaml:"max_page_size" toml:"max_page_size" env:"COMMENTS_MAX_PAGE_SIZE"`
+}
+
+type HealthConfig struct {
+	// CheckTimeout bounds each readiness check.
+	CheckTimeout Duration `yaml:"check_timeout" toml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT"`
+}
+
+type MigrationsConfig struct {
+	Dir string `yaml:"dir" toml:"dir" env:"MIGRATIONS_DIR"`
+}
+
+// Default returns the configuration used when neither a file nor env vars
+// override a setting.
+func Default() *Config {
+	return &Config{
+		Env: "development",
+		Server: ServerConfig{
+			Port:              "8080",
+			ReadTimeout:       Duration{15 * time.Second},
+			ReadHeaderTimeout: Duration{5 * time.Second},
+			WriteTimeout:      Duration{30 * time.Second},
+			IdleTimeout:       Duration{60 * time.Second},
+			ShutdownTimeout:   Duration{25 * time.Second},
+		},
+		Logging: LoggingConfig{
+			Level:                  "info",
+			Format:                 "json",
+			SlowOperationThreshold: Duration{500 * time.Millisecond},
+			RedactVariables:        []string{"password", "token", "secret", "authorization", "apiKey", "email"},
+		},
+		Tracing: TracingConfig{
+			Exporter:            "none",
+			OTLPEndpoint:        "http://localhost:4318",
+			ServiceName:         "graphql-backend",
+			SampleRatio:         1,
+			ResolverSampleRatio: 0.1,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Database: DatabaseConfig{
+			Host:           "127.0.0.1",
+			Port:           "3306",
+			User:           "root",
+			Password:       "password",
+			Name:           "graphql_db",
+			ConnectRetries: 5,
+			RetryDelay:     Duration{2 * time.Second},
+
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: Duration{5 * time.Minute},
+			ConnMaxIdleTime: Duration{time.Minute},
+			DialTimeout:     Duration{5 * time.Second},
+			ReadTimeout:     Duration{30 * time.Second},
+			WriteTimeout:    Duration{30 * time.Second},
+			Timezone:        "Local",
+			TLS: DatabaseTLSConfig{
+				Mode: "disabled",
+			},
+			Replicas: ReplicasConfig{
+				MaxLag:              Duration{5 * time.Second},
+				HealthCheckInterval: Duration{5 * time.Second},
+			},
+			StatsInterval: Duration{time.Minute},
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   []string{"*"},
+			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"*"},
+			AllowCredentials: true,
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:        10,
+			MaxComplexity:   1000,
+			DefaultListSize: 10,
+			MaxPageSize:     1000,
+			MaxBatchSize:    10,
+			PersistedQueries: PersistedQueriesConfig{
+				Cache:     "memory",
+				CacheSize: 1000,
+				MaxStored: 10000,
+			},
+		},
+		DataLoader: DataLoaderConfig{
+			Wait:      Duration{16 * time.Millisecond},
+			MaxBatch:  1000,
+			Cache:     "memory",
+			CacheSize: 1000,
+			Shared: SharedCacheConfig{
+				Backend: "none",
+				Size:    10000,
+				TTL:     Duration{time.Minute},
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Rate:  100,
+			Burst: 2000,
+		},
+		Comments: CommentsConfig{
+			MaxDepth:    5,
+			MaxPageSize: 100,
+		},
+		Migrations: MigrationsConfig{
+			Dir: "migrations",
+		},
+		Health: HealthConfig{
+			CheckTimeout: Duration{2 * time.Second},
+		},
+	}
+}
+
+// Load builds the configuration from path (YAML or TOML, chosen by extension)
+// and the environment. An empty path falls back to $CONFIG_FILE; when that is
+// unset too only defaults and env vars are used.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, c)
+	case ".toml":
+		err = toml.Unmarshal(content, c)
+	default:
+		return fmt.Errorf("unsupported config file format %q (use .yaml, .yml or .toml)", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var problems []string
+
+	if err := validatePort(c.Server.Port); err != nil {
+		problems = append(problems, "server.port: "+err.Error())
+	}
+	for _, d := range []struct {
+		name  string
+		value Duration
+	}{
+		{"read_timeout", c.Server.ReadTimeout},
+		{"read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"write_timeout", c.Server.WriteTimeout},
+		{"idle_timeout", c.Server.IdleTimeout},
+	} {
+		if d.value.Duration < 0 {
+			problems = append(problems, "server."+d.name+": must not be negative")
+		}
+	}
+	if c.Server.ShutdownTimeout.Duration <= 0 {
+		problems = append(problems, "server.shutdown_timeout: must be positive")
+	}
+	if err := validatePort(c.Database.Port); err != nil {
+		problems = append(problems, "database.port: "+err.Error())
+	}
+	if c.Database.Host == "" {
+		problems = append(problems, "database.host: must not be empty")
+	}
+	if c.Database.User == "" {
+		problems = append(problems, "database.user: must not be empty")
+	}
+	if c.Database.Name == "" {
+		problems = append(problems, "database.name: must not be empty")
+	}
+	if c.Database.ConnectRetries < 1 {
+		problems = append(problems, "database.connect_retries: must be at least 1")
+	}
+	if c.Database.RetryDelay.Duration < 0 {
+		problems = append(problems, "database.retry_delay: must not be negative")
+	}
+	if c.Database.MaxOpenConns < 0 {
+		problems = append(problems, "database.max_open_conns: must not be negative")
+	}
+	if c.Database.MaxIdleConns < 0 {
+		problems = append(problems, "database.max_idle_conns: must not be negative")
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		problems = append(problems, "database.max_idle_conns: must not exceed max_open_conns")
+	}
+	for _, d := range []struct {
+		name  string
+		value Duration
+	}{
+		{"conn_max_lifetime", c.Database.ConnMaxLifetime},
+		{"conn_max_idle_time", c.Database.ConnMaxIdleTime},
+		{"dial_timeout", c.Database.DialTimeout},
+		{"read_timeout", c.Database.ReadTimeout},
+		{"write_timeout", c.Database.WriteTimeout},
+		{"stats_interval", c.Database.StatsInterval},
+	} {
+		if d.value.Duration < 0 {
+			problems = append(problems, "database."+d.name+": must not be negative")
+		}
+	}
+	if _, err := time.LoadLocation(c.Database.Timezone); err != nil {
+		problems = append(problems, "database.timezone: "+err.Error())
+	}
+	if len(c.Database.Replicas.Hosts) > 0 && c.Database.Replicas.HealthCheckInterval.Duration <= 0 {
+		problems = append(problems, "database.replicas.health_check_interval: must be positive")
+	}
+	if c.Database.Replicas.MaxLag.Duration < 0 {
+		problems = append(problems, "database.replicas.max_lag: must not be negative")
+	}
+	switch c.Database.TLS.Mode {
+	case "", "disabled", "preferred", "skip-verify", "required":
+	case "custom":
+		if c.Database.TLS.CAFile == "" {
+			problems = append(problems, "database.tls.ca_file: required when mode is custom")
+		}
+		if (c.Database.TLS.CertFile == "") != (c.Database.TLS.KeyFile == "") {
+			problems = append(problems, "database.tls: cert_file and key_file must be set together")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("database.tls.mode: unknown mode %q", c.Database.TLS.Mode))
+	}
+	switch strings.ToLower(c.Logging.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("logging.level: unknown level %q", c.Logging.Level))
+	}
+	if c.Logging.Format != "json" && c.Logging.Format != "text" {
+		problems = append(problems, fmt.Sprintf("logging.format: unknown format %q", c.Logging.Format))
+	}
+	if c.Logging.SlowOperationThreshold.Duration < 0 {
+		problems = append(problems, "logging.slow_operation_threshold: must not be negative")
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		problems = append(problems, fmt.Sprintf("tracing.exporter: unknown exporter %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "tracing.sample_ratio: must be between 0 and 1")
+	}
+	if c.Tracing.ResolverSampleRatio < 0 || c.Tracing.ResolverSampleRatio > 1 {
+		problems = append(problems, "tracing.resolver_sample_ratio: must be between 0 and 1")
+	}
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		problems = append(problems, "metrics.path: must start with /")
+	}
+	if len(c.CORS.AllowedOrigins) == 0 {
+		problems = append(problems, "cors.allowed_origins: must not be empty")
+	}
+	if c.GraphQL.MaxDepth < 0 {
+		problems = append(problems, "graphql.max_depth: must not be negative")
+	}
+	if c.GraphQL.MaxComplexity < 0 {
+		problems = append(problems, "graphql.max_complexity: must not be negative")
+	}
+	if c.GraphQL.DefaultListSize < 1 {
+		problems = append(problems, "graphql.default_list_size: must be at least 1")
+	}
+	if c.GraphQL.MaxPageSize < 1 {
+		problems = append(problems, "graphql.max_page_size: must be at least 1")
+	}
+	if c.GraphQL.MaxBatchSize < 0 {
+		problems = append(problems, "graphql.max_batch_size: must not be negative")
+	}
+	switch c.GraphQL.PersistedQueries.Cache {
+	case "memory", "mysql", "none":
+	default:
+		problems = append(problems, fmt.Sprintf("graphql.persisted_queries.cache: unknown cache %q", c.GraphQL.PersistedQueries.Cache))
+	}
+	if c.GraphQL.PersistedQueries.CacheSize < 1 {
+		problems = append(problems, "graphql.persisted_queries.cache_size: must be at least 1")
+	}
+	if c.GraphQL.PersistedQueries.MaxStored < 1 {
+		problems = append(problems, "graphql.persisted_queries.max_stored: must be at least 1")
+	}
+	if c.AllowlistOnly() && c.GraphQL.PersistedQueries.Manifest == "" {
+		problems = append(problems, "graphql.persisted_queries.manifest: required when allowlist_only is set or env is production")
+	}
+	problems = append(problems, validateLoader("dataloader", c.DataLoader)...)
+	switch c.DataLoader.Shared.Backend {
+	case "none":
+	case "memory":
+		if c.DataLoader.Shared.Size < 1 {
+			problems = append(problems, "dataloader.shared.size: must be at least 1")
+		}
+		if c.DataLoader.Shared.TTL.Duration <= 0 {
+			problems = append(problems, "dataloader.shared.ttl: must be positive")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("dataloader.shared.backend: unknown backend %q", c.DataLoader.Shared.Backend))
+	}
+	names := make([]string, 0, len(c.DataLoader.Loaders))
+	for name := range c.DataLoader.Loaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !slices.Contains(loaderNames, name) {
+			problems = append(problems, fmt.Sprintf("dataloader.loaders.%s: unknown loader (known: %s)", name, strings.Join(loaderNames, ", ")))
+			continue
+		}
+		problems = append(problems, validateLoader("dataloader.loaders."+name, c.DataLoader.Loader(name))...)
+	}
+	if c.RateLimit.Rate < 1 {
+		problems = append(problems, "rate_limit.rate: must be at least 1")
+	}
+	if c.RateLimit.Burst < 1 {
+		problems = append(problems, "rate_limit.burst: must be at least 1")
+	}
+	for i, op := range c.RateLimit.Operations {
+		if op.Name == "" {
+			problems = append(problems, fmt.Sprintf("rate_limit.operations[%d].name: must not be empty", i))
+		}
+		if op.Rate < 1 || op.Burst < 1 {
+			problems = append(problems, fmt.Sprintf("rate_limit.operations[%d]: rate and burst must be at least 1", i))
+		}
+	}
+	if c.Comments.MaxDepth < 0 || c.Comments.MaxDepth > 15 {
+		problems = append(problems, "comments.max_depth: must be between 0 and 15")
+	}
+	if c.Comments.MaxPageSize < 1 {
+		problems = append(problems, "comments.max_page_size: must be at least 1")
+	}
+	if c.Migrations.Dir == "" {
+		problems = append(problems, "migrations.dir: must not be empty")
+	}
+	if c.Health.CheckTimeout.Duration <= 0 {
+		problems = append(problems, "health.check_timeout: must be positive")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func validateLoader(prefix string, l DataLoaderConfig) []string {
+	var problems []string
+	if l.Wait.Duration < 0 {
+		problems = append(problems, prefix+".wait: must not be negative")
+	}
+	if l.MaxBatch < 0 {
+		problems = append(problems, prefix+".max_batch: must not be negative")
+	}
+	switch l.Cache {
+	case "memory", "none":
+	case "lru":
+		if l.CacheSize < 1 {
+			problems = append(problems, prefix+".cache_size: must be at least 1 with the lru cache")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("%s.cache: unknown cache %q", prefix, l.Cache))
+	}
+	return problems
+}
+
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("%q is not a valid port", port)
+	}
+	return nil
+}
+
+// AllowlistOnly reports whether only the queries of the persisted query
+// manifest may be executed. This is always the case in production.
+func (c *Config) AllowlistOnly() bool {
+	return c.GraphQL.PersistedQueries.AllowlistOnly || c.Env == "production"
+}
+
+// Redacted returns a copy with every field tagged secret:"true" masked, safe
+// to print or log.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redact(reflect.ValueOf(&redacted).Elem())
+	return &redacted
+}
+
+// String renders the redacted configuration as YAML.
+func (c *Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("<config: %v>", err)
+	}
+	return string(out)
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(Duration{}):
+			redact(field)
+		case t.Field(i).Tag.Get("secret") == "true" && field.Kind() == reflect.String && field.String() != "":
+			field.SetString("******")
+		}
+	}
+}
+
+// applyEnv overrides every field that has an env tag and whose variable is set.
+func applyEnv(v reflect.Value, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(Duration{}) {
+			if err := applyEnv(field, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := t.Field(i).Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		value, ok := lookup(key)
+		if !ok || value == "" {
+			continue
+		}
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case []string:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case Duration:
+		var d Duration
+		if err := d.UnmarshalText([]byte(value)); err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(d))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// Duration is a time.Duration written as a string such as "2s" or "500ms" in
+// config files and env vars.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"graphql-backend/telemetry"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("graphql-backend/database")
+
+// Conn is the pool handed to repositories by Reader and Writer. It counts
+// the statements run for each request (see WithQueryCounter), and traces and
+// times each one under the name of the repository method that ran it.
+type Conn struct {
+	*sql.DB
+}
+
+func (c Conn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, st := startStatement(ctx, query)
+	rows, err := c.DB.QueryContext(ctx, query, args...)
+	st.end(err)
+	return rows, err
+}
+
+func (c Conn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, st := startStatement(ctx, query)
+	row := c.DB.QueryRowContext(ctx, query, args...)
+	st.end(row.Err())
+	return row
+}
+
+func (c Conn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, st := startStatement(ctx, query)
+	result, err := c.DB.ExecContext(ctx, query, args...)
+	st.end(err)
+	return result, err
+}
+
+// BeginTx starts a transaction whose statements are counted, traced and
+// timed like those run on the pool.
+func (c Conn) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := c.DB.BeginTx(ctx, opts)
+	return Tx{tx}, err
+}
+
+// Tx is a transaction started with Conn.BeginTx.
+type Tx struct {
+	*sql.Tx
+}
+
+func (t Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, st := startStatement(ctx, query)
+	rows, err := t.Tx.QueryContext(ctx, query, args...)
+	st.end(err)
+	return rows, err
+}
+
+func (t Tx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, st := startStatement(ctx, query)
+	row := t.Tx.QueryRowContext(ctx, query, args...)
+	st.end(row.Err())
+	return row
+}
+
+func (t Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, st := startStatement(ctx, query)
+	result, err := t.Tx.ExecContext(ctx, query, args...)
+	st.end(err)
+	return result, err
+}
+
+type statement struct {
+	method string
+	start  time.Time
+	span   trace.Span
+}
+
+func startStatement(ctx context.Context, query string) (context.Context, *statement) {
+	countQuery(ctx)
+
+	st := &statement{method: caller(), start: time.Now()}
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	ctx, st.span = tracer.Start(ctx, st.method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("db.system", "mysql"),
+		attribute.String("db.operation", strings.ToUpper(operation)),
+		attribute.String("db.statement", query),
+	))
+	return ctx, st
+}
+
+func (st *statement) end(err error) {
+	telemetry.SQLDuration.WithLabelValues(st.method).Observe(time.Since(st.start).Seconds())
+
+	if err != nil && err != sql.ErrNoRows {
+		st.span.RecordError(err)
+		st.span.SetStatus(codes.Error, err.Error())
+	}
+	st.span.End()
+}
+
+// caller names the function that called a Conn method, e.g.
+// "UserRepository.GetAll".
+func caller() string {
+	pc, _, _, ok := runtime.Caller(3)
+	if !ok {
+		return "sql"
+	}
+	name := runtime.FuncForPC(pc).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	if _, rest, ok := strings.Cut(name, "."); ok {
+		name = rest
+	}
+	return strings.NewReplacer("(*", "", ")", "").Replace(name)
+}
+
+type queryCounterKey struct{}
+
+// WithQueryCounter starts counting the statements run with ctx or any
+// context derived from it.
+func WithQueryCounter(ctx context.Context) context.Context {
+	return context.WithValue(ctx, queryCounterKey{}, new(atomic.Int64))
+}
+
+// QueryCount returns the number of statements run since WithQueryCounter.
+func QueryCount(ctx context.Context) int64 {
+	if n, ok := ctx.Value(queryCounterKey{}).(*atomic.Int64); ok {
+		return n.Load()
+	}
+	return 0
+}
+
+func countQuery(ctx context.Context) {
+	if n, ok := ctx.Value(queryCounterKey{}).(*atomic.Int64); ok {
+		n.Add(1)
+	}
+}
@@ -2,45 +2,46 @@ package database
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"log"
-	"os"
+	"sync/atomic"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"graphql-backend/config"
+
+	"github.com/go-sql-driver/mysql"
 )
 
+// DB is the primary connection pool, which all writes go through, plus an
+// optional set of read replicas. See Reader for how reads are routed.
 type DB struct {
 	*sql.DB
-}
 
-func NewDB() (*DB, error) {
-	dbUser := getEnv("DB_USER", "root")
-	dbPassword := getEnv("DB_PASSWORD", "password")
-	dbHost := getEnv("DB_HOST", "127.0.0.1")
-	dbPort := getEnv("DB_PORT", "3306")
-	dbName := getEnv("DB_NAME", "graphql_db")
+	replicas []*replica
+	next     atomic.Uint64
+	maxLag   time.Duration
+	// staleness is how far behind the primary a replica in rotation can be.
+	staleness time.Duration
+	stop      chan struct{}
+}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
+func NewDB(cfg config.DatabaseConfig) (*DB, error) {
+	mc, err := mysqlConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure database: %w", err)
+	}
 
-	maxRetries := 5
-	retryDelay := time.Second * 2
+	connector, err := mysql.NewConnector(mc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure database: %w", err)
+	}
 
-	var db *sql.DB
-	var err error
+	maxRetries := cfg.ConnectRetries
+	retryDelay := cfg.RetryDelay.Duration
 
 	for i := 0; i < maxRetries; i++ {
-		db, err = sql.Open("mysql", dsn)
-		if err != nil {
-			log.Printf("Attempt %d failed to open database: %v", i+1, err)
-			if i < maxRetries-1 {
-				time.Sleep(retryDelay)
-				retryDelay *= 2
-				continue
-			}
-			return nil, fmt.Errorf("failed to open database after %d attempts: %w", maxRetries, err)
-		}
+		db := openPool(connector, cfg)
 
 		if err := db.Ping(); err != nil {
 			log.Printf("Attempt %d failed to ping database: %v", i+1, err)
@@ -54,12 +55,36 @@ func NewDB() (*DB, error) {
 		}
 
 		log.Println("Database connection established")
-		return &DB{db}, nil
+
+		primary := &DB{DB: db, stop: make(chan struct{})}
+		if err := primary.openReplicas(cfg); err != nil {
+			primary.Close()
+			return nil, err
+		}
+		return primary, nil
 	}
 
 	return nil, fmt.Errorf("failed to establish database connection after %d attempts", maxRetries)
 }
 
+func openPool(connector driver.Connector, cfg config.DatabaseConfig) *sql.DB {
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime.Duration)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime.Duration)
+	return db
+}
+
+// Close stops the replica health checks and closes every pool.
+func (db *DB) Close() error {
+	close(db.stop)
+	for _, r := range db.replicas {
+		r.db.Close()
+	}
+	return db.DB.Close()
+}
+
 func (db *DB) CreateTables() error {
 	query := `
 	CREATE TABLE IF NOT EXISTS users (
@@ -78,10 +103,3 @@ func (db *DB) CreateTables() error {
 	log.Println("Users table created successfully")
 	return nil
 }
-
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
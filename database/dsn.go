@@ -0,0 +1,82 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"graphql-backend/config"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlConfig translates the database settings into a driver config.
+func mysqlConfig(cfg config.DatabaseConfig) (*mysql.Config, error) {
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", cfg.Timezone, err)
+	}
+
+	mc := mysql.NewConfig()
+	mc.User = cfg.User
+	mc.Passwd = cfg.Password
+	mc.Net = "tcp"
+	mc.Addr = net.JoinHostPort(cfg.Host, cfg.Port)
+	mc.DBName = cfg.Name
+	mc.Params = map[string]string{"charset": "utf8mb4"}
+	mc.ParseTime = true
+	mc.Loc = loc
+	mc.Timeout = cfg.DialTimeout.Duration
+	mc.ReadTimeout = cfg.ReadTimeout.Duration
+	mc.WriteTimeout = cfg.WriteTimeout.Duration
+
+	switch cfg.TLS.Mode {
+	case "", "disabled":
+	case "preferred":
+		mc.TLSConfig = "preferred"
+	case "skip-verify":
+		mc.TLSConfig = "skip-verify"
+	case "required":
+		mc.TLS = &tls.Config{ServerName: cfg.TLS.ServerName}
+	case "custom":
+		tlsConfig, err := customTLS(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		mc.TLS = tlsConfig
+	default:
+		return nil, fmt.Errorf("unknown TLS mode %q", cfg.TLS.Mode)
+	}
+
+	return mc, nil
+}
+
+func customTLS(cfg config.DatabaseTLSConfig) (*tls.Config, error) {
+	pem, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+	}
+
+	tlsConfig := &tls.Config{
+		RootCAs:    pool,
+		ServerName: cfg.ServerName,
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
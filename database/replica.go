@@ -0,0 +1,186 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"graphql-backend/config"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type replica struct {
+	addr    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+func (db *DB) openReplicas(cfg config.DatabaseConfig) error {
+	db.maxLag = cfg.Replicas.MaxLag.Duration
+	// ラグは HealthCheckInterval ごとにしか確認しないので、その間にも遅れうる
+	db.staleness = cfg.Replicas.MaxLag.Duration + cfg.Replicas.HealthCheckInterval.Duration
+
+	for _, host := range cfg.Replicas.Hosts {
+		replicaCfg := cfg
+		replicaCfg.Host, replicaCfg.Port = host, cfg.Port
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			replicaCfg.Host, replicaCfg.Port = h, p
+		}
+
+		mc, err := mysqlConfig(replicaCfg)
+		if err != nil {
+			return fmt.Errorf("failed to configure replica %s: %w", host, err)
+		}
+		connector, err := mysql.NewConnector(mc)
+		if err != nil {
+			return fmt.Errorf("failed to configure replica %s: %w", host, err)
+		}
+
+		db.replicas = append(db.replicas, &replica{
+			addr: mc.Addr,
+			db:   openPool(connector, replicaCfg),
+		})
+	}
+
+	if len(db.replicas) == 0 {
+		return nil
+	}
+
+	// 最初のチェックが終わるまではプライマリに読み込みを流す
+	db.checkReplicas()
+	go db.watchReplicas(cfg.Replicas.HealthCheckInterval.Duration)
+
+	log.Printf("Read replicas configured: %d", len(db.replicas))
+	return nil
+}
+
+// Reader returns the pool a read should use: a healthy replica picked
+// round-robin, or the primary when the request has written (see MarkWrite) or
+// no replica is currently healthy.
+func (db *DB) Reader(ctx context.Context) Conn {
+	if len(db.replicas) == 0 || usesPrimary(ctx) {
+		return Conn{db.DB}
+	}
+
+	start := db.next.Add(1)
+	for i := range uint64(len(db.replicas)) {
+		r := db.replicas[(start+i)%uint64(len(db.replicas))]
+		if r.healthy.Load() {
+			return Conn{r.db}
+		}
+	}
+	return Conn{db.DB}
+}
+
+// ReplicaStaleness is how long after a write a replica may still return the
+// old rows; 0 when reads never go to a replica.
+func (db *DB) ReplicaStaleness() time.Duration {
+	if len(db.replicas) == 0 {
+		return 0
+	}
+	return db.staleness
+}
+
+// Writer returns the primary pool.
+func (db *DB) Writer() Conn {
+	return Conn{db.DB}
+}
+
+func (db *DB) watchReplicas(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stop:
+			return
+		case <-ticker.C:
+			db.checkReplicas()
+		}
+	}
+}
+
+func (db *DB) checkReplicas() {
+	for _, r := range db.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := r.check(ctx, db.maxLag)
+		cancel()
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) != healthy {
+			if healthy {
+				log.Printf("Replica %s is healthy, routing reads to it", r.addr)
+			} else {
+				log.Printf("Warning: replica %s removed from rotation: %v", r.addr, err)
+			}
+		}
+	}
+}
+
+// check pings the replica and compares its replication delay with maxLag.
+// A server that reports no replication status is treated as up to date.
+func (r *replica) check(ctx context.Context, maxLag time.Duration) error {
+	if err := r.db.PingContext(ctx); err != nil {
+		return err
+	}
+
+	lag, ok, err := replicationLag(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	if ok && maxLag > 0 && lag > maxLag {
+		return fmt.Errorf("replication lag %s exceeds %s", lag, maxLag)
+	}
+	return nil
+}
+
+func replicationLag(ctx context.Context, db *sql.DB) (time.Duration, bool, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		// MySQL 8.0.22 未満
+		rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS")
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to read replication status: %w", err)
+		}
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, false, err
+	}
+	if !rows.Next() {
+		return 0, false, rows.Err()
+	}
+
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, false, fmt.Errorf("failed to scan replication status: %w", err)
+	}
+
+	for i, column := range columns {
+		if !strings.EqualFold(column, "Seconds_Behind_Source") && !strings.EqualFold(column, "Seconds_Behind_Master") {
+			continue
+		}
+		if values[i] == nil {
+			return 0, false, fmt.Errorf("replication is not running")
+		}
+		seconds, err := strconv.Atoi(string(values[i]))
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid replication lag %q: %w", values[i], err)
+		}
+		return time.Duration(seconds) * time.Second, true, nil
+	}
+	return 0, false, nil
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+)
+
+type sessionKey struct{}
+type primaryKey struct{}
+
+// session tracks whether the current request has written to the primary.
+type session struct {
+	wrote atomic.Bool
+}
+
+// Middleware gives each request a session so that, once a mutation has
+// written, the remaining reads of that request go to the primary and see it.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), sessionKey{}, &session{})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// MarkWrite records that ctx's request has written. The returned context is
+// pinned to the primary even when there is no request session, so a
+// repository can safely read back the row it just wrote.
+func MarkWrite(ctx context.Context) context.Context {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+	return UsePrimary(ctx)
+}
+
+// UsePrimary pins reads made with the returned context to the primary
+// without marking the request as having written.
+func UsePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func usesPrimary(ctx context.Context) bool {
+	if pinned, _ := ctx.Value(primaryKey{}).(bool); pinned {
+		return true
+	}
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.wrote.Load()
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// ReportStats logs the pool statistics every interval until ctx is
+// cancelled. Waits for a connection since the previous report mean the pool
+// is saturated and are logged as a warning.
+func (db *DB) ReportStats(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var previous sql.DBStats
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := db.Stats()
+			log.Printf("DB pool: open=%d in_use=%d idle=%d max_open=%d wait_count=%d wait_duration=%s",
+				stats.OpenConnections, stats.InUse, stats.Idle, stats.MaxOpenConnections, stats.WaitCount, stats.WaitDuration)
+
+			if waits := stats.WaitCount - previous.WaitCount; waits > 0 {
+				log.Printf("Warning: DB pool saturated, %d queries waited %s for a connection",
+					waits, stats.WaitDuration-previous.WaitDuration)
+			}
+			previous = stats
+		}
+	}
+}
+
+// Collectors returns Prometheus collectors exposing sql.DBStats of the
+// primary and every replica, labelled db_name="primary" or the replica
+// address.
+func (db *DB) Collectors() []prometheus.Collector {
+	cs := []prometheus.Collector{collectors.NewDBStatsCollector(db.DB, "primary")}
+	for _, r := range db.replicas {
+		cs = append(cs, collectors.NewDBStatsCollector(r.db, r.addr))
+	}
+	return cs
+}
@@ -0,0 +1,113 @@
+// Package batching accepts Apollo-style batched requests: a JSON array of
+// operations POSTed to /query, answered with an array of results in the
+// same order.
+package batching
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Transport runs the operations of a batch concurrently on the request's
+// context, so they share its Loaders and their keys are batched together.
+// It must be added before transport.POST, which handles everything that is
+// not an array. Each operation gets a single result, so operations using
+// @defer must be rejected by incremental.Guard.
+type Transport struct {
+	MaxBatchSize int
+}
+
+var _ graphql.Transport = Transport{}
+
+func (t Transport) Supports(r *http.Request) bool {
+	if r.Method != http.MethodPost || r.Header.Get("Upgrade") != "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return false
+	}
+
+	// 配列かどうかを見るためにボディを読み、後続のトランスポート用に戻しておく
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+func (t Transport) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/json")
+
+	start := graphql.Now()
+	var batch []*graphql.RawParams
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, exec.DispatchError(ctx, gqlerror.List{gqlerror.Errorf("json request body could not be decoded: %v", err)}))
+		return
+	}
+	if len(batch) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, exec.DispatchError(ctx, gqlerror.List{gqlerror.Errorf("batch must contain at least one operation")}))
+		return
+	}
+	if len(batch) > t.MaxBatchSize {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, exec.DispatchError(ctx, gqlerror.List{gqlerror.Errorf("batch of %d operations exceeds the limit of %d", len(batch), t.MaxBatchSize)}))
+		return
+	}
+	readTime := graphql.TraceTiming{Start: start, End: graphql.Now()}
+
+	responses := make([]*graphql.Response, len(batch))
+	var wg sync.WaitGroup
+	for i, params := range batch {
+		if params == nil {
+			params = &graphql.RawParams{}
+		}
+		params.Headers = r.Header
+		params.ReadTime = readTime
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			rc, opErr := exec.CreateOperationContext(ctx, params)
+			if opErr != nil {
+				responses[i] = exec.DispatchError(graphql.WithOperationContext(ctx, rc), opErr)
+				return
+			}
+			handler, opCtx := exec.DispatchOperation(ctx, rc)
+			responses[i] = handler(opCtx)
+			// @defer は incremental.Guard で弾かれるが、続きの応答が残っていればゴルーチンを残さないよう読み捨てる
+			for handler(opCtx) != nil {
+			}
+		}()
+	}
+	wg.Wait()
+
+	b, err := json.Marshal(responses)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		writeJSON(w, &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("failed to encode batch response")}})
+		return
+	}
+	_, _ = w.Write(b)
+}
+
+func writeJSON(w io.Writer, resp *graphql.Response) {
+	b, _ := json.Marshal(resp)
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,168 @@
+// Package cachecontrol turns @cacheControl schema hints into HTTP caching
+// headers for GET queries.
+package cachecontrol
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Policy is how long and by whom a response may be cached.
+type Policy struct {
+	MaxAge  int
+	Private bool
+}
+
+// Header formats p as a Cache-Control value.
+func (p Policy) Header() string {
+	if p.MaxAge <= 0 {
+		// 保存はさせず、ETag による再検証だけ許可する
+		return "no-cache"
+	}
+	if p.Private {
+		return fmt.Sprintf("private, max-age=%d", p.MaxAge)
+	}
+	return fmt.Sprintf("public, max-age=%d", p.MaxAge)
+}
+
+// policy accumulates the minimum over the fields of one response.
+type policy struct {
+	mu      sync.Mutex
+	maxAge  int
+	private bool
+}
+
+func (p *policy) restrict(maxAge *int, private bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if maxAge != nil && *maxAge < p.maxAge {
+		p.maxAge = *maxAge
+	}
+	p.private = p.private || private
+}
+
+// Extension computes the policy of every query response from the hints of
+// the fields it resolved and hands it to Middleware. Responses of mutations,
+// subscriptions and anything with errors are not cacheable.
+type Extension struct {
+	schema *ast.Schema
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Extension{}
+
+func New() *Extension {
+	return &Extension{}
+}
+
+func (e *Extension) ExtensionName() string {
+	return "CacheControl"
+}
+
+func (e *Extension) Validate(schema graphql.ExecutableSchema) error {
+	e.schema = schema.Schema()
+	return nil
+}
+
+type policyKey struct{}
+
+func (e *Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	target := fromContext(ctx)
+	if target == nil || !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	p := &policy{maxAge: math.MaxInt}
+	resp := next(context.WithValue(ctx, policyKey{}, p))
+
+	result := Policy{}
+	op := graphql.GetOperationContext(ctx).Operation
+	if resp != nil && len(resp.Errors) == 0 && op != nil && op.Operation == ast.Query && p.maxAge != math.MaxInt {
+		result = Policy{MaxAge: p.maxAge, Private: p.private}
+	}
+	target.set(result)
+
+	return resp
+}
+
+func (e *Extension) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	p, ok := ctx.Value(policyKey{}).(*policy)
+	fc := graphql.GetFieldContext(ctx)
+	if !ok || fc == nil || fc.Field.Definition == nil || strings.HasPrefix(fc.Field.Name, "__") {
+		return next(ctx)
+	}
+
+	maxAge, private := e.hint(fc)
+	p.restrict(maxAge, private)
+
+	return next(ctx)
+}
+
+// hint returns the maxAge a field imposes (nil when it inherits its
+// parent's) and whether it is private.
+func (e *Extension) hint(fc *graphql.FieldContext) (*int, bool) {
+	def := fc.Field.Definition
+	fieldAge, fieldScope := readDirective(def.Directives)
+
+	typeDef := e.schema.Types[def.Type.Name()]
+	composite := typeDef != nil && typeDef.IsCompositeType()
+
+	var typeAge *int
+	var typeScope string
+	if composite {
+		typeAge, typeScope = readDirective(typeDef.Directives)
+	}
+	private := fieldScope == "PRIVATE" || typeScope == "PRIVATE"
+
+	switch {
+	case fieldAge != nil:
+		return fieldAge, private
+	case typeAge != nil:
+		return typeAge, private
+	case composite || e.isRoot(fc.Object):
+		zero := 0
+		return &zero, private
+	default:
+		return nil, private
+	}
+}
+
+func (e *Extension) isRoot(object string) bool {
+	for _, root := range []*ast.Definition{e.schema.Query, e.schema.Mutation, e.schema.Subscription} {
+		if root != nil && root.Name == object {
+			return true
+		}
+	}
+	return false
+}
+
+func readDirective(directives ast.DirectiveList) (*int, string) {
+	d := directives.ForName("cacheControl")
+	if d == nil {
+		return nil, ""
+	}
+
+	var maxAge *int
+	if arg := d.Arguments.ForName("maxAge"); arg != nil {
+		if v, err := arg.Value.Value(nil); err == nil {
+			if n, ok := v.(int64); ok {
+				age := int(n)
+				maxAge = &age
+			}
+		}
+	}
+	var scope string
+	if arg := d.Arguments.ForName("scope"); arg != nil {
+		scope = arg.Value.Raw
+	}
+	return maxAge, scope
+}
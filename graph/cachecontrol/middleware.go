@@ -0,0 +1,107 @@
+package cachecontrol
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type targetKey struct{}
+
+// target receives the policy computed by the Extension.
+type target struct {
+	mu     sync.Mutex
+	policy *Policy
+}
+
+func (t *target) set(p Policy) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.policy == nil || p.MaxAge < t.policy.MaxAge {
+		t.policy = &p
+	}
+	t.policy.Private = t.policy.Private || p.Private
+}
+
+func fromContext(ctx context.Context) *target {
+	t, _ := ctx.Value(targetKey{}).(*target)
+	return t
+}
+
+// Middleware buffers GET responses on /query to add Cache-Control from the
+// response's policy and a content ETag, answering a matching If-None-Match
+// with 304 Not Modified. Other methods, websocket upgrades and streamed
+// responses pass through untouched.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.Header.Get("Upgrade") != "" || streaming(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		t := &target{}
+		buf := &bufferedWriter{header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(buf, r.WithContext(context.WithValue(r.Context(), targetKey{}, t)))
+
+		if buf.status == http.StatusOK {
+			if t.policy != nil {
+				w.Header().Set("Cache-Control", t.policy.Header())
+				if t.policy.Private {
+					w.Header().Add("Vary", "Authorization")
+				}
+			} else {
+				w.Header().Set("Cache-Control", "no-store")
+			}
+
+			sum := sha256.Sum256(buf.body.Bytes())
+			etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+			w.Header().Set("ETag", etag)
+			if matches(r.Header.Get("If-None-Match"), etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		w.WriteHeader(buf.status)
+		_, _ = w.Write(buf.body.Bytes())
+	})
+}
+
+func streaming(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "text/event-stream") || strings.Contains(accept, "multipart/mixed")
+}
+
+// matches reports whether an If-None-Match header lists etag. Weak
+// comparison is used, as RFC 9110 requires for If-None-Match.
+func matches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+type bufferedWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
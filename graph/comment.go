@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"graphql-backend/graph/model"
+	"graphql-backend/models"
+	"graphql-backend/models/loaders"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// defaultPageSize is used when a client passes first: null.
+const defaultPageSize = 10
+
+const commentCursorPrefix = "comment:"
+
+// Cursors are base64 encoded so that clients treat them as opaque.
+func encodeCommentCursor(id int) string {
+	return base64.StdEncoding.EncodeToString([]byte(commentCursorPrefix + strconv.Itoa(id)))
+}
+
+func decodeCommentCursor(cursor string) (int, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), commentCursorPrefix) {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(string(raw), commentCursorPrefix))
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return id, nil
+}
+
+// commentPage loads the page of comments selected by first and after for
+// the parent id. One more row than requested is loaded to tell whether
+// another page follows.
+func (r *Resolver) commentPage(ctx context.Context, loader *dataloader.Loader[loaders.PageKey, []*models.Comment], id int, first *int, after *string) (*model.CommentConnection, error) {
+	limit := defaultPageSize
+	if first != nil {
+		limit = *first
+	}
+	if limit < 0 || limit > r.comments.MaxPageSize {
+		return nil, fmt.Errorf("first must be between 0 and %d", r.comments.MaxPageSize)
+	}
+
+	afterID := 0
+	if after != nil {
+		var err error
+		if afterID, err = decodeCommentCursor(*after); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := loader.Load(ctx, loaders.PageKey{ID: id, Limit: limit + 1, After: afterID})()
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &model.CommentConnection{
+		Edges:    []*model.CommentEdge{},
+		PageInfo: &model.PageInfo{HasNextPage: len(rows) > limit},
+	}
+	for _, row := range rows[:min(limit, len(rows))] {
+		conn.Edges = append(conn.Edges, &model.CommentEdge{
+			Cursor: encodeCommentCursor(row.ID),
+			Node:   newComment(row),
+		})
+	}
+	if n := len(conn.Edges); n > 0 {
+		conn.PageInfo.EndCursor = &conn.Edges[n-1].Cursor
+	}
+
+	return conn, nil
+}
+
+func newComment(c *models.Comment) *model.Comment {
+	comment := &model.Comment{
+		ID:        strconv.Itoa(c.ID),
+		PostID:    strconv.Itoa(c.PostID),
+		AuthorID:  c.UserID,
+		Body:      c.Body,
+		Depth:     c.Depth,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+	if c.ParentID != nil {
+		parentID := strconv.Itoa(*c.ParentID)
+		comment.ParentID = &parentID
+	}
+	return comment
+}
@@ -0,0 +1,121 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.78
+
+import (
+	"context"
+	"fmt"
+	"graphql-backend/graph/model"
+	"graphql-backend/models/loaders"
+	"strconv"
+	"strings"
+)
+
+// Author is the resolver for the author field.
+func (r *commentResolver) Author(ctx context.Context, obj *model.Comment) (*model.User, error) {
+	user, err := loaders.FromContext(ctx).UserByID.Load(ctx, obj.AuthorID)()
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.User{
+		ID:        model.NewUserID(user.ID),
+		Name:      user.Name,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}, nil
+}
+
+// Replies is the resolver for the replies field.
+func (r *commentResolver) Replies(ctx context.Context, obj *model.Comment, first *int, after *string) (*model.CommentConnection, error) {
+	commentID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID: %w", err)
+	}
+
+	return r.commentPage(ctx, loaders.FromContext(ctx).CommentReplies, commentID, first, after)
+}
+
+// AddComment is the resolver for the addComment field.
+func (r *mutationResolver) AddComment(ctx context.Context, input model.AddCommentInput) (*model.Comment, error) {
+	postID, err := strconv.Atoi(input.PostID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid post ID: %w", err)
+	}
+	authorID, err := strconv.Atoi(input.AuthorID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID: %w", err)
+	}
+	var parentID *int
+	if input.ParentID != nil {
+		id, err := strconv.Atoi(*input.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid comment ID: %w", err)
+		}
+		parentID = &id
+	}
+	if strings.TrimSpace(input.Body) == "" {
+		return nil, fmt.Errorf("comment body must not be empty")
+	}
+
+	comment, err := r.commentRepo.Create(ctx, postID, parentID, authorID, input.Body, r.comments.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	loaders.FromContext(ctx).ClearComments(ctx)
+
+	return newComment(comment), nil
+}
+
+// EditComment is the resolver for the editComment field.
+func (r *mutationResolver) EditComment(ctx context.Context, id string, body string) (*model.Comment, error) {
+	commentID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment ID: %w", err)
+	}
+	if strings.TrimSpace(body) == "" {
+		return nil, fmt.Errorf("comment body must not be empty")
+	}
+
+	comment, err := r.commentRepo.Update(ctx, commentID, body)
+	if err != nil {
+		return nil, err
+	}
+	loaders.FromContext(ctx).ClearComments(ctx)
+
+	return newComment(comment), nil
+}
+
+// DeleteComment is the resolver for the deleteComment field.
+func (r *mutationResolver) DeleteComment(ctx context.Context, id string) (bool, error) {
+	commentID, err := strconv.Atoi(id)
+	if err != nil {
+		return false, fmt.Errorf("invalid comment ID: %w", err)
+	}
+
+	err = r.commentRepo.Delete(ctx, commentID)
+	if err != nil {
+		return false, err
+	}
+	loaders.FromContext(ctx).ClearComments(ctx)
+
+	return true, nil
+}
+
+// Comments is the resolver for the comments field.
+func (r *postResolver) Comments(ctx context.Context, obj *model.Post, first *int, after *string) (*model.CommentConnection, error) {
+	postID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid post ID: %w", err)
+	}
+
+	return r.commentPage(ctx, loaders.FromContext(ctx).CommentsByPostID, postID, first, after)
+}
+
+// Comment returns CommentResolver implementation.
+func (r *Resolver) Comment() CommentResolver { return &commentResolver{r} }
+
+type commentResolver struct{ *Resolver }
@@ -0,0 +1,256 @@
+// Package complexity rejects operations that are nested too deeply or would
+// be too expensive to execute, before any resolver runs.
+package complexity
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+
+	"graphql-backend/config"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	extensionName = "QueryLimits"
+
+	ErrDepthLimit      = "DEPTH_LIMIT_EXCEEDED"
+	ErrComplexityLimit = "COMPLEXITY_LIMIT_EXCEEDED"
+)
+
+// paginationArgs are the arguments whose value is used as the number of items
+// a list field returns.
+var paginationArgs = []string{"first", "last", "limit"}
+
+// Stats is stored on the operation context so later middleware (logging,
+// rate limiting) can reuse the computed cost.
+type Stats struct {
+	Depth      int
+	Complexity int
+}
+
+// Limits is a gqlgen extension enforcing the configured max depth and
+// complexity. Every field costs 1 plus its children, unless overridden with
+// @cost(value:); list fields multiply their children's cost by the pagination
+// argument, the field's @cost(listSize:) or the configured default. Object
+// fields taking a pagination argument (connections) are charged like a list
+// of that many items. Pagination arguments are clamped to [0, MaxPageSize]
+// and the sums saturate, so no argument can make an operation cheaper.
+type Limits struct {
+	cfg config.GraphQLConfig
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &Limits{}
+
+func New(cfg config.GraphQLConfig) *Limits {
+	return &Limits{cfg: cfg}
+}
+
+func (l *Limits) ExtensionName() string {
+	return extensionName
+}
+
+func (l *Limits) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (l *Limits) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	op := opCtx.Operation
+	if op == nil {
+		return nil
+	}
+
+	stats := Calculate(op, opCtx.Variables, l.cfg.DefaultListSize, l.cfg.MaxPageSize)
+	opCtx.Stats.SetExtension(extensionName, &stats)
+
+	if l.cfg.MaxDepth > 0 && stats.Depth > l.cfg.MaxDepth {
+		err := gqlerror.Errorf("operation has depth %d, which exceeds the limit of %d", stats.Depth, l.cfg.MaxDepth)
+		errcode.Set(err, ErrDepthLimit)
+		err.Extensions["depth"] = stats.Depth
+		err.Extensions["limit"] = l.cfg.MaxDepth
+		return err
+	}
+
+	if l.cfg.MaxComplexity > 0 && stats.Complexity > l.cfg.MaxComplexity {
+		err := gqlerror.Errorf("operation has complexity %d, which exceeds the limit of %d", stats.Complexity, l.cfg.MaxComplexity)
+		errcode.Set(err, ErrComplexityLimit)
+		err.Extensions["complexity"] = stats.Complexity
+		err.Extensions["limit"] = l.cfg.MaxComplexity
+		return err
+	}
+
+	return nil
+}
+
+// GetStats returns the depth and complexity computed for the current
+// operation, or nil when the extension is not installed.
+func GetStats(ctx context.Context) *Stats {
+	if !graphql.HasOperationContext(ctx) {
+		return nil
+	}
+	return StatsOf(graphql.GetOperationContext(ctx))
+}
+
+// StatsOf returns the stats recorded on opCtx, for extensions that run before
+// the operation context is attached to ctx.
+func StatsOf(opCtx *graphql.OperationContext) *Stats {
+	stats, _ := opCtx.Stats.GetExtension(extensionName).(*Stats)
+	return stats
+}
+
+// Calculate computes the depth and complexity of op. Introspection fields are
+// free so that tooling keeps working under tight limits. Pagination arguments
+// count as at most maxPageSize items.
+func Calculate(op *ast.OperationDefinition, vars map[string]any, defaultListSize, maxPageSize int) Stats {
+	c := calculator{vars: vars, defaultListSize: defaultListSize, maxPageSize: maxPageSize}
+	complexity := c.selectionSet(op.SelectionSet, 1)
+	return Stats{Depth: c.depth, Complexity: complexity}
+}
+
+type calculator struct {
+	vars            map[string]any
+	defaultListSize int
+	maxPageSize     int
+	depth           int
+}
+
+func (c *calculator) selectionSet(set ast.SelectionSet, depth int) int {
+	total := 0
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name, "__") {
+				continue
+			}
+			if depth > c.depth {
+				c.depth = depth
+			}
+			total = add(total, c.field(s, depth))
+		case *ast.FragmentSpread:
+			if s.Definition != nil {
+				total = add(total, c.selectionSet(s.Definition.SelectionSet, depth))
+			}
+		case *ast.InlineFragment:
+			total = add(total, c.selectionSet(s.SelectionSet, depth))
+		}
+	}
+	return total
+}
+
+func (c *calculator) field(field *ast.Field, depth int) int {
+	children := c.selectionSet(field.SelectionSet, depth+1)
+	if field.Definition == nil {
+		return add(1, children)
+	}
+
+	value := 1
+	listSize := c.defaultListSize
+	if directive := field.Definition.Directives.ForName("cost"); directive != nil {
+		if v, ok := directiveInt(directive, "value"); ok {
+			value = max(v, 0)
+		}
+		if v, ok := directiveInt(directive, "listSize"); ok {
+			listSize = max(v, 0)
+		}
+	}
+
+	args := field.ArgumentMap(c.vars)
+	for _, name := range paginationArgs {
+		arg, present := args[name]
+		if !present {
+			continue
+		}
+		n, ok := toInt(arg)
+		if !ok && arg == nil {
+			// first: null はリゾルバ側で既定のページサイズになるので、同じ数で数える
+			n, ok = c.defaultPageSize(field.Definition, name, listSize), true
+		}
+		if ok {
+			return add(value, mul(min(max(n, 0), c.maxPageSize), children))
+		}
+	}
+
+	if field.Definition.Type.Elem == nil {
+		return add(value, children)
+	}
+	return add(value, mul(listSize, children))
+}
+
+// defaultPageSize is the page size a resolver uses when the pagination
+// argument name is null: the argument's schema default, else listSize.
+func (c *calculator) defaultPageSize(def *ast.FieldDefinition, name string, listSize int) int {
+	if arg := def.Arguments.ForName(name); arg != nil && arg.DefaultValue != nil {
+		if v, err := arg.DefaultValue.Value(nil); err == nil {
+			if n, ok := toInt(v); ok {
+				return n
+			}
+		}
+	}
+	return listSize
+}
+
+// add and mul saturate at math.MaxInt; all operands are non-negative.
+func add(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+func mul(a, b int) int {
+	if a != 0 && b > math.MaxInt/a {
+		return math.MaxInt
+	}
+	return a * b
+}
+
+func directiveInt(directive *ast.Directive, name string) (int, bool) {
+	arg := directive.Arguments.ForName(name)
+	if arg == nil || arg.Value == nil {
+		return 0, false
+	}
+	v, err := arg.Value.Value(nil)
+	if err != nil {
+		return 0, false
+	}
+	return toInt(v)
+}
+
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return floatToInt(n), true
+	case json.Number:
+		// 範囲外の値は ±Inf とエラーになるので、上限・下限に丸めて扱う
+		f, err := n.Float64()
+		if err != nil && !math.IsInf(f, 0) {
+			return 0, false
+		}
+		return floatToInt(f), true
+	}
+	return 0, false
+}
+
+// floatToInt converts f, clamping values outside the int range instead of
+// leaving the result to the platform.
+func floatToInt(f float64) int {
+	switch {
+	case f >= math.MaxInt:
+		return math.MaxInt
+	case f <= math.MinInt:
+		return math.MinInt
+	}
+	return int(f)
+}
@@ -0,0 +1,112 @@
+// Package incremental keeps @defer and @stream to the transports that
+// deliver every payload of a response. The others send only the initial
+// payload, which would leave the deferred fragments and streamed items
+// missing and their goroutines blocked.
+package incremental
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const ErrUnsupported = "INCREMENTAL_DELIVERY_UNSUPPORTED"
+
+// directives are the directives whose payloads follow the initial one.
+var directives = []string{"defer", "stream"}
+
+type supportedKey struct{}
+
+// Transport marks the requests of a transport that reads the response
+// handler until it is exhausted, such as transport.MultipartMixed and
+// transport.Websocket.
+type Transport struct {
+	graphql.Transport
+}
+
+func (t Transport) Do(w http.ResponseWriter, r *http.Request, exec graphql.GraphExecutor) {
+	ctx := context.WithValue(r.Context(), supportedKey{}, true)
+	t.Transport.Do(w, r.WithContext(ctx), exec)
+}
+
+// Supported reports whether ctx belongs to a request served by Transport.
+func Supported(ctx context.Context) bool {
+	supported, _ := ctx.Value(supportedKey{}).(bool)
+	return supported
+}
+
+// Guard is a gqlgen extension rejecting operations that use @defer or
+// @stream on requests not served by Transport, e.g. plain POST, GET or
+// batches.
+type Guard struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = Guard{}
+
+func (g Guard) ExtensionName() string {
+	return "IncrementalDeliveryGuard"
+}
+
+func (g Guard) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (g Guard) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	if Supported(ctx) || opCtx.Operation == nil {
+		return nil
+	}
+	name := find(opCtx.Operation.SelectionSet, opCtx.Variables, map[string]bool{})
+	if name == "" {
+		return nil
+	}
+	err := gqlerror.Errorf("@%s requires a request with Accept: multipart/mixed or a websocket connection", name)
+	errcode.Set(err, ErrUnsupported)
+	return err
+}
+
+// find returns the name of the first incremental directive in set, looking
+// into fragments too. Directives disabled with if: false are ignored.
+func find(set ast.SelectionSet, vars map[string]any, visited map[string]bool) string {
+	for _, sel := range set {
+		var (
+			dirs     ast.DirectiveList
+			children ast.SelectionSet
+		)
+		switch sel := sel.(type) {
+		case *ast.Field:
+			dirs, children = sel.Directives, sel.SelectionSet
+		case *ast.InlineFragment:
+			dirs, children = sel.Directives, sel.SelectionSet
+		case *ast.FragmentSpread:
+			dirs = sel.Directives
+			// 同じフラグメントを何度も辿らない
+			if sel.Definition != nil && !visited[sel.Name] {
+				visited[sel.Name] = true
+				children = sel.Definition.SelectionSet
+			}
+		}
+		for _, name := range directives {
+			if enabled(dirs.ForName(name), vars) {
+				return name
+			}
+		}
+		if name := find(children, vars, visited); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+func enabled(d *ast.Directive, vars map[string]any) bool {
+	if d == nil {
+		return false
+	}
+	on, ok := d.ArgumentMap(vars)["if"].(bool)
+	return !ok || on
+}
@@ -0,0 +1,205 @@
+// Package logging writes one structured log line per GraphQL operation.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"graphql-backend/config"
+	"graphql-backend/database"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewLogger returns the process logger for cfg. It is meant to be installed
+// with slog.SetDefault, which also routes the standard log package through it.
+func NewLogger(cfg config.LoggingConfig) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level(cfg.Level)}
+	if cfg.Format == "text" {
+		return slog.New(slog.NewTextHandler(os.Stdout, opts))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+}
+
+func level(name string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// Operations is a gqlgen extension logging every operation with its timings,
+// counters and error codes. Operations slower than the configured threshold
+// are logged as warnings. Operations delivered in several payloads (@defer)
+// are logged once, when the last payload is sent, with the counters and
+// error codes of all payloads.
+type Operations struct {
+	logger *slog.Logger
+	slow   time.Duration
+	redact map[string]bool
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = &Operations{}
+
+func New(logger *slog.Logger, cfg config.LoggingConfig) *Operations {
+	redact := make(map[string]bool, len(cfg.RedactVariables))
+	for _, name := range cfg.RedactVariables {
+		redact[strings.ToLower(name)] = true
+	}
+	return &Operations{
+		logger: logger,
+		slow:   cfg.SlowOperationThreshold.Duration,
+		redact: redact,
+	}
+}
+
+func (o *Operations) ExtensionName() string {
+	return "OperationLogging"
+}
+
+func (o *Operations) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// operationState collects what an operation's payloads have in common until
+// the last one is logged.
+type operationState struct {
+	resolvers atomic.Int64
+	codes     []string
+}
+
+type operationStateKey struct{}
+
+func (o *Operations) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	responses := next(ctx)
+	state := &operationState{}
+	return func(ctx context.Context) *graphql.Response {
+		return responses(context.WithValue(ctx, operationStateKey{}, state))
+	}
+}
+
+func (o *Operations) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	if fc := graphql.GetFieldContext(ctx); fc != nil && fc.IsResolver {
+		if state, ok := ctx.Value(operationStateKey{}).(*operationState); ok {
+			state.resolvers.Add(1)
+		}
+	}
+	return next(ctx)
+}
+
+func (o *Operations) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	state, ok := ctx.Value(operationStateKey{}).(*operationState)
+	if !ok {
+		// 実行前に弾かれた（検証・複雑度・レート制限）エラーは InterceptOperation を通らない
+		state = &operationState{}
+		ctx = context.WithValue(ctx, operationStateKey{}, state)
+	}
+
+	resp := next(ctx)
+	if resp == nil || !graphql.HasOperationContext(ctx) {
+		return resp
+	}
+	state.codes = appendErrorCodes(state.codes, resp)
+	if resp.HasNext != nil && *resp.HasNext {
+		return resp
+	}
+
+	opCtx := graphql.GetOperationContext(ctx)
+	duration := time.Since(opCtx.Stats.OperationStart)
+
+	attrs := []slog.Attr{
+		slog.String("request_id", RequestID(ctx)),
+		slog.String("operation_name", operationName(opCtx)),
+		slog.String("operation_type", operationType(opCtx)),
+		slog.Any("variables", o.redactValue("", opCtx.Variables)),
+		slog.Int64("resolvers", state.resolvers.Load()),
+		slog.Int64("sql_queries", database.QueryCount(ctx)),
+		slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		attrs = append(attrs, slog.String("trace_id", sc.TraceID().String()))
+	}
+	if len(state.codes) > 0 {
+		attrs = append(attrs, slog.Any("error_codes", state.codes))
+	}
+
+	lvl := slog.LevelInfo
+	if o.slow > 0 && duration >= o.slow {
+		lvl = slog.LevelWarn
+		attrs = append(attrs, slog.Bool("slow", true))
+	}
+	o.logger.LogAttrs(ctx, lvl, "graphql operation", attrs...)
+
+	// サブスクリプションはイベントごとに 1 行なので、次のイベント用に集計をリセットする
+	state.resolvers.Store(0)
+	state.codes = nil
+
+	return resp
+}
+
+// operationName prefers the name in the document, since clients often omit
+// the operationName parameter.
+func operationName(opCtx *graphql.OperationContext) string {
+	if opCtx.Operation != nil && opCtx.Operation.Name != "" {
+		return opCtx.Operation.Name
+	}
+	return opCtx.OperationName
+}
+
+func operationType(opCtx *graphql.OperationContext) string {
+	if opCtx.Operation == nil {
+		return ""
+	}
+	return string(opCtx.Operation.Operation)
+}
+
+// appendErrorCodes adds the extensions.code values of resp's errors that
+// are not in codes yet; errors without a code are reported as INTERNAL.
+func appendErrorCodes(codes []string, resp *graphql.Response) []string {
+	for _, err := range resp.Errors {
+		code, _ := err.Extensions["code"].(string)
+		if code == "" {
+			code = "INTERNAL"
+		}
+		if !slices.Contains(codes, code) {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// redactValue replaces the values of sensitive variables, at any depth of
+// input objects, with "[REDACTED]".
+func (o *Operations) redactValue(key string, value any) any {
+	if o.redact[strings.ToLower(key)] {
+		return "[REDACTED]"
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = o.redactValue(k, item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = o.redactValue(key, item)
+		}
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,123 @@
+// Package metrics records Prometheus metrics for GraphQL operations.
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"graphql-backend/telemetry"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// maxOperationNameLength caps the operation label so that a long name in the
+// manifest cannot blow up the series size.
+const maxOperationNameLength = 64
+
+// maxSeenOperations is how many distinct operation names are labelled when
+// there is no manifest to take them from.
+const maxSeenOperations = 100
+
+// otherOperation labels every operation whose name is not allowed.
+const otherOperation = "other"
+
+// Extension observes the latency of every operation and counts its errors by
+// extensions.code. Errors without a code are counted as INTERNAL. Operations
+// delivered in several payloads (@defer) are observed once, when the last
+// payload is sent.
+type Extension struct {
+	operations *operationNames
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = Extension{}
+
+// New returns an Extension that labels operations by name. Operation names
+// come from clients, so to keep the label's cardinality bounded only the
+// names in operations (e.g. those of the persisted query manifest) are used.
+// Without any, the first maxSeenOperations distinct names are. Every other
+// operation is labelled "other".
+func New(operations []string) Extension {
+	names := &operationNames{names: make(map[string]bool, len(operations)), open: len(operations) == 0}
+	for _, name := range operations {
+		names.names[name] = true
+	}
+	return Extension{operations: names}
+}
+
+// operationNames is the set of operation names used as label values.
+type operationNames struct {
+	mu    sync.Mutex
+	names map[string]bool
+	// open is set when names are added as they are seen.
+	open bool
+}
+
+func (o *operationNames) allowed(name string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.names[name] {
+		return true
+	}
+	if !o.open || name == "" || len(o.names) >= maxSeenOperations {
+		return false
+	}
+	o.names[name] = true
+	return true
+}
+
+func (e Extension) ExtensionName() string {
+	return "Metrics"
+}
+
+func (e Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (e Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	resp := next(ctx)
+	if resp == nil {
+		return resp
+	}
+
+	for _, err := range resp.Errors {
+		code, _ := err.Extensions["code"].(string)
+		if code == "" {
+			code = "INTERNAL"
+		}
+		telemetry.OperationErrors.WithLabelValues(code).Inc()
+	}
+
+	// @defer の途中のペイロードでは所要時間を記録しない（最後の 1 回だけ）
+	if resp.HasNext != nil && *resp.HasNext {
+		return resp
+	}
+
+	if graphql.HasOperationContext(ctx) {
+		opCtx := graphql.GetOperationContext(ctx)
+		name, opType := opCtx.OperationName, ""
+		if opCtx.Operation != nil {
+			opType = string(opCtx.Operation.Operation)
+			if opCtx.Operation.Name != "" {
+				name = opCtx.Operation.Name
+			}
+		}
+		telemetry.OperationDuration.WithLabelValues(e.operationLabel(name), opType).Observe(time.Since(opCtx.Stats.OperationStart).Seconds())
+	}
+
+	return resp
+}
+
+func (e Extension) operationLabel(name string) string {
+	if !e.operations.allowed(name) {
+		return otherOperation
+	}
+	if len(name) > maxOperationNameLength {
+		return name[:maxOperationNameLength]
+	}
+	return name
+}
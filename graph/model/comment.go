@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Comment keeps the author's ID so that the author resolver can load the
+// user through the UserByID loader.
+type Comment struct {
+	ID        string    `json:"id"`
+	PostID    string    `json:"postId"`
+	ParentID  *string   `json:"parentId,omitempty"`
+	AuthorID  int       `json:"-"`
+	Body      string    `json:"body"`
+	Depth     int       `json:"depth"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -3,6 +3,10 @@
 package model
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
 	"time"
 )
 
@@ -11,6 +15,25 @@ type Node interface {
 	GetID() string
 }
 
+type AddCommentInput struct {
+	PostID string `json:"postId"`
+	// Set to reply to a comment of the same post.
+	ParentID *string `json:"parentId,omitempty"`
+	AuthorID string  `json:"authorId"`
+	Body     string  `json:"body"`
+}
+
+type CommentConnection struct {
+	Edges    []*CommentEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+type CommentEdge struct {
+	// Opaque cursor to pass as `after` to fetch the comments following this one.
+	Cursor string   `json:"cursor"`
+	Node   *Comment `json:"node"`
+}
+
 type CreateUserInput struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
@@ -19,17 +42,42 @@ type CreateUserInput struct {
 type Mutation struct {
 }
 
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
+}
+
 type Post struct {
 	ID        string    `json:"id"`
 	Title     string    `json:"title"`
 	Content   string    `json:"content"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	// Top-level comments, oldest first.
+	Comments *CommentConnection `json:"comments"`
+	Tags     []*Tag             `json:"tags"`
+}
+
+type PostFilter struct {
+	// Only posts carrying this tag; normalized like a slug before matching.
+	Tag *string `json:"tag,omitempty"`
 }
 
 type Query struct {
 }
 
+// A tag categorising posts. Tags are identified by their slug, the normalized
+// form of the tag as typed: lower case, with runs of anything but letters and
+// digits replaced by a hyphen ("Go  Modules!" becomes "go-modules").
+type Tag struct {
+	ID   string `json:"id"`
+	Slug string `json:"slug"`
+	// The tag as typed when it was first attached.
+	Name string `json:"name"`
+	// Number of posts carrying the tag.
+	PostCount int `json:"postCount"`
+}
+
 type UpdateUserInput struct {
 	Name  *string `json:"name,omitempty"`
 	Email *string `json:"email,omitempty"`
@@ -43,3 +91,58 @@ type User struct {
 	UpdatedAt time.Time `json:"updatedAt"`
 	Posts     []*Post   `json:"posts"`
 }
+
+type CacheControlScope string
+
+const (
+	CacheControlScopePublic  CacheControlScope = "PUBLIC"
+	CacheControlScopePrivate CacheControlScope = "PRIVATE"
+)
+
+var AllCacheControlScope = []CacheControlScope{
+	CacheControlScopePublic,
+	CacheControlScopePrivate,
+}
+
+func (e CacheControlScope) IsValid() bool {
+	switch e {
+	case CacheControlScopePublic, CacheControlScopePrivate:
+		return true
+	}
+	return false
+}
+
+func (e CacheControlScope) String() string {
+	return string(e)
+}
+
+func (e *CacheControlScope) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = CacheControlScope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid CacheControlScope", str)
+	}
+	return nil
+}
+
+func (e CacheControlScope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *CacheControlScope) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e CacheControlScope) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,72 @@
+package persisted
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const ErrNotAllowed = "PERSISTED_QUERY_NOT_ALLOWED"
+
+// Allowlist is a gqlgen extension that only executes queries registered in a
+// manifest. Clients may send just the APQ hash, or the full query as long as
+// its hash is registered. It replaces extension.AutomaticPersistedQuery.
+type Allowlist struct {
+	Manifest *Manifest
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationParameterMutator
+} = Allowlist{}
+
+func (a Allowlist) ExtensionName() string {
+	return "PersistedQueryAllowlist"
+}
+
+func (a Allowlist) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (a Allowlist) MutateOperationParameters(ctx context.Context, rawParams *graphql.RawParams) *gqlerror.Error {
+	var extension struct {
+		Sha256  string `mapstructure:"sha256Hash"`
+		Version int64  `mapstructure:"version"`
+	}
+	if ext := rawParams.Extensions["persistedQuery"]; ext != nil {
+		if err := mapstructure.Decode(ext, &extension); err != nil {
+			return gqlerror.Errorf("invalid persisted query extension data")
+		}
+		if extension.Version != 1 {
+			return gqlerror.Errorf("unsupported persisted query version")
+		}
+	}
+
+	hash := extension.Sha256
+	if rawParams.Query != "" {
+		if computed := Hash(rawParams.Query); hash != "" && computed != hash {
+			return gqlerror.Errorf("provided persisted query hash does not match query")
+		} else {
+			hash = computed
+		}
+	}
+
+	if hash == "" {
+		err := gqlerror.Errorf("only persisted queries are allowed")
+		errcode.Set(err, ErrNotAllowed)
+		return err
+	}
+
+	query, ok := a.Manifest.Get(hash)
+	if !ok {
+		err := gqlerror.Errorf("persisted query %s is not registered", hash)
+		errcode.Set(err, ErrNotAllowed)
+		return err
+	}
+
+	rawParams.Query = query
+	return nil
+}
@@ -0,0 +1,95 @@
+// Package persisted provides the stores behind automatic persisted queries
+// (APQ) and the allowlist of queries registered at build time.
+package persisted
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// Manifest maps sha256 hashes to the query documents clients were built with.
+type Manifest struct {
+	queries map[string]string
+}
+
+// manifestFile accepts both the Apollo persisted query manifest
+// ({"operations": [{"id", "body"}]}) and a plain {"<sha256>": "<query>"} map.
+type manifestFile struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Operations []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+// LoadManifest reads and verifies a manifest file. Every hash must match its
+// query so a tampered manifest cannot smuggle in arbitrary operations.
+func LoadManifest(path string) (*Manifest, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persisted query manifest: %w", err)
+	}
+
+	queries := make(map[string]string)
+
+	var apollo manifestFile
+	if err := json.Unmarshal(content, &apollo); err == nil && apollo.Operations != nil {
+		for _, op := range apollo.Operations {
+			queries[op.ID] = op.Body
+		}
+	} else if err := json.Unmarshal(content, &queries); err != nil {
+		return nil, fmt.Errorf("failed to parse persisted query manifest %s: %w", path, err)
+	}
+
+	for hash, query := range queries {
+		if Hash(query) != hash {
+			return nil, fmt.Errorf("persisted query manifest %s: hash %s does not match its query", path, hash)
+		}
+	}
+
+	return &Manifest{queries: queries}, nil
+}
+
+// Get returns the query registered under hash.
+func (m *Manifest) Get(hash string) (string, bool) {
+	query, ok := m.queries[hash]
+	return query, ok
+}
+
+// Len returns the number of registered queries.
+func (m *Manifest) Len() int {
+	return len(m.queries)
+}
+
+// OperationNames returns the names of the operations defined by the
+// registered queries. Queries that fail to parse are skipped; they are
+// rejected at validation anyway.
+func (m *Manifest) OperationNames() []string {
+	var names []string
+	for _, query := range m.queries {
+		doc, err := parser.ParseQuery(&ast.Source{Input: query})
+		if err != nil {
+			continue
+		}
+		for _, op := range doc.Operations {
+			if op.Name != "" {
+				names = append(names, op.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Hash is the APQ hash of a query document.
+func Hash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
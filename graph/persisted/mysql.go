@@ -0,0 +1,69 @@
+package persisted
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"graphql-backend/database"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+)
+
+// MySQLCache stores APQ registrations in the persisted_queries table so they
+// survive restarts and are shared between instances. Recently used queries
+// are kept in an in-process LRU in front of the table. Since any client can
+// register a query, the table keeps at most maxStored of the most recent
+// registrations.
+type MySQLCache struct {
+	db        *database.DB
+	local     graphql.Cache[string]
+	maxStored int
+}
+
+var _ graphql.Cache[string] = (*MySQLCache)(nil)
+
+func NewMySQLCache(db *database.DB, localSize, maxStored int) *MySQLCache {
+	return &MySQLCache{db: db, local: lru.New[string](localSize), maxStored: maxStored}
+}
+
+func (c *MySQLCache) Get(ctx context.Context, hash string) (string, bool) {
+	if query, ok := c.local.Get(ctx, hash); ok {
+		return query, true
+	}
+
+	var query string
+	err := c.db.Reader(ctx).QueryRowContext(ctx, "SELECT query FROM persisted_queries WHERE hash = ?", hash).Scan(&query)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to read persisted query %s: %v", hash, err)
+		}
+		return "", false
+	}
+
+	c.local.Add(ctx, hash, query)
+	return query, true
+}
+
+func (c *MySQLCache) Add(ctx context.Context, hash, query string) {
+	c.local.Add(ctx, hash, query)
+
+	result, err := c.db.Writer().ExecContext(ctx, "INSERT IGNORE INTO persisted_queries (hash, query) VALUES (?, ?)", hash, query)
+	if err != nil {
+		log.Printf("Failed to store persisted query %s: %v", hash, err)
+		return
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return
+	}
+
+	// 登録が増えたときだけ、上限を超えた古いものを消す
+	_, err = c.db.Writer().ExecContext(ctx, `DELETE p FROM persisted_queries p JOIN (
+		SELECT hash FROM persisted_queries ORDER BY created_at DESC, hash LIMIT ?, 18446744073709551615
+	) old ON old.hash = p.hash`, c.maxStored)
+	if err != nil {
+		log.Printf("Failed to evict persisted queries: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package graph
+
+import (
+	"strconv"
+
+	"graphql-backend/graph/model"
+	"graphql-backend/models"
+)
+
+func newPost(p *models.Post) *model.Post {
+	return &model.Post{
+		ID:        strconv.Itoa(p.ID),
+		Title:     p.Title,
+		Content:   p.Content,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+func newTag(t *models.Tag) *model.Tag {
+	return &model.Tag{
+		ID:        strconv.Itoa(t.ID),
+		Slug:      t.Slug,
+		Name:      t.Name,
+		PostCount: t.PostCount,
+	}
+}
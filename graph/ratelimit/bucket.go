@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// bucket is a token bucket refilled continuously at rate tokens per second.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// buckets holds one bucket per client (and operation, for overridden limits).
+type buckets struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func newBuckets() *buckets {
+	return &buckets{buckets: make(map[string]*bucket)}
+}
+
+// charge is the cost of an operation in the bucket under key.
+type charge struct {
+	key   string
+	limit Limit
+	cost  int
+}
+
+// take removes the tokens of every charge, or none when one of the buckets
+// has not enough; then the time until all of them will is returned.
+func (b *buckets) take(now time.Time, charges ...charge) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var wait time.Duration
+	bks := make([]*bucket, len(charges))
+	for i, c := range charges {
+		bk, ok := b.buckets[c.key]
+		if !ok {
+			bk = &bucket{tokens: float64(c.limit.Burst), last: now}
+			b.buckets[c.key] = bk
+		}
+		bk.tokens = math.Min(float64(c.limit.Burst), bk.tokens+now.Sub(bk.last).Seconds()*float64(c.limit.Rate))
+		bk.last = now
+		bks[i] = bk
+
+		if missing := float64(c.cost) - bk.tokens; missing > 0 {
+			wait = max(wait, time.Duration(math.Ceil(missing/float64(c.limit.Rate)*float64(time.Second))))
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+
+	for i, c := range charges {
+		bks[i].tokens -= float64(c.cost)
+	}
+	return true, 0
+}
+
+// prune drops buckets that have been idle long enough to be full again, so
+// the map does not grow with every client ever seen.
+func (b *buckets) prune(now time.Time, idle time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for key, bk := range b.buckets {
+		if now.Sub(bk.last) > idle {
+			delete(b.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+)
+
+type userKey struct{}
+
+type apiKeyKey struct{}
+
+// WithUser marks the request as made by an authenticated user so it is
+// limited per user rather than per API key or IP. Authentication middleware
+// must run before Middleware for this to take effect.
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+// WithAPIKey marks the request as made with an API key that authentication
+// middleware has verified, so it is limited per key rather than per IP.
+func WithAPIKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// ClientKey identifies who a request is charged to: the authenticated user,
+// else the verified API key, else the client IP. The X-API-Key header alone
+// is never used, since a made-up key per request would get a fresh bucket
+// each time. API keys are hashed so they never sit in memory in the clear.
+func ClientKey(r *http.Request, trustForwardedFor bool) string {
+	if user, ok := r.Context().Value(userKey{}).(string); ok && user != "" {
+		return "user:" + user
+	}
+	if key, ok := r.Context().Value(apiKeyKey{}).(string); ok && key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	return "ip:" + clientIP(r, trustForwardedFor)
+}
+
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,207 @@
+// Package ratelimit throttles clients with token buckets. Each operation
+// costs its complexity in tokens, so a few expensive queries use up a
+// client's budget as fast as many cheap ones.
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"graphql-backend/config"
+	"graphql-backend/graph/complexity"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/errcode"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const ErrRateLimited = "RATE_LIMITED"
+
+// Limit is a token bucket size (Burst) and refill rate (Rate tokens per second).
+type Limit struct {
+	Rate  int
+	Burst int
+}
+
+// Limiter is a gqlgen extension that charges every operation to the client
+// identified by Middleware. Operations with their own configured limit are
+// also charged to a separate bucket per client, so an override can only
+// tighten the default limit.
+type Limiter struct {
+	cfg        config.RateLimitConfig
+	operations map[string]Limit
+	buckets    *buckets
+	now        func() time.Time
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationContextMutator
+} = &Limiter{}
+
+func New(cfg config.RateLimitConfig) *Limiter {
+	operations := make(map[string]Limit, len(cfg.Operations))
+	for _, op := range cfg.Operations {
+		operations[op.Name] = Limit{Rate: op.Rate, Burst: op.Burst}
+	}
+	return &Limiter{
+		cfg:        cfg,
+		operations: operations,
+		buckets:    newBuckets(),
+		now:        time.Now,
+	}
+}
+
+func (l *Limiter) ExtensionName() string {
+	return "RateLimit"
+}
+
+func (l *Limiter) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+// Prune periodically removes idle buckets until ctx is done.
+func (l *Limiter) Prune(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			l.buckets.prune(now, l.idleAfter())
+		}
+	}
+}
+
+// idleAfter is how long the slowest bucket takes to refill completely.
+func (l *Limiter) idleAfter() time.Duration {
+	longest := fullAfter(Limit{Rate: l.cfg.Rate, Burst: l.cfg.Burst})
+	for _, limit := range l.operations {
+		longest = max(longest, fullAfter(limit))
+	}
+	return longest
+}
+
+func fullAfter(l Limit) time.Duration {
+	return time.Duration(float64(l.Burst) / float64(l.Rate) * float64(time.Second))
+}
+
+func (l *Limiter) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	client := clientFromContext(ctx)
+	if client == nil {
+		return nil
+	}
+
+	cost := 1
+	if stats := complexity.StatsOf(opCtx); stats != nil && stats.Complexity > cost {
+		cost = stats.Complexity
+	}
+
+	// An operation costing more than a bucket holds could never run, so it
+	// is charged a full bucket instead; the complexity limit caps it anyway.
+	limit := Limit{Rate: l.cfg.Rate, Burst: l.cfg.Burst}
+	charges := []charge{{key: client.key, limit: limit, cost: min(cost, limit.Burst)}}
+	name := opCtx.OperationName
+	if opCtx.Operation != nil && opCtx.Operation.Name != "" {
+		name = opCtx.Operation.Name
+	}
+	// 名前は客側が決められるので、専用のバケットに加えて既定のバケットからも引く
+	if opLimit, ok := l.operations[name]; ok {
+		charges = append(charges, charge{key: client.key + "|" + name, limit: opLimit, cost: min(cost, opLimit.Burst)})
+	}
+
+	ok, wait := l.buckets.take(l.now(), charges...)
+	if ok {
+		return nil
+	}
+
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	client.setRetryAfter(retryAfter)
+
+	err := gqlerror.Errorf("rate limit exceeded, retry in %d seconds", retryAfter)
+	errcode.Set(err, ErrRateLimited)
+	err.Extensions["retryAfter"] = retryAfter
+	err.Extensions["cost"] = charges[0].cost
+	return err
+}
+
+type ctxKey struct{}
+
+type client struct {
+	key string
+
+	mu         sync.Mutex
+	retryAfter int
+}
+
+func (c *client) setRetryAfter(seconds int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retryAfter = max(c.retryAfter, seconds)
+}
+
+func clientFromContext(ctx context.Context) *client {
+	c, _ := ctx.Value(ctxKey{}).(*client)
+	return c
+}
+
+// Middleware identifies the client of each request and adds a Retry-After
+// header to responses whose operations were rate limited.
+func Middleware(cfg config.RateLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c := &client{key: ClientKey(r, cfg.TrustForwardedFor)}
+			ctx := context.WithValue(r.Context(), ctxKey{}, c)
+			next.ServeHTTP(&retryAfterWriter{ResponseWriter: w, client: c}, r.WithContext(ctx))
+		})
+	}
+}
+
+type retryAfterWriter struct {
+	http.ResponseWriter
+	client      *client
+	wroteHeader bool
+}
+
+func (w *retryAfterWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.client.mu.Lock()
+		if w.client.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(w.client.retryAfter))
+		}
+		w.client.mu.Unlock()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *retryAfterWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Hijack and Flush are forwarded for websocket upgrades and streamed
+// responses.
+func (w *retryAfterWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *retryAfterWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, which
+// websocket upgrades and deadline changes rely on.
+func (w *retryAfterWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -1,7 +1,7 @@
 package graph
 
 import (
-	"database/sql"
+	"graphql-backend/config"
 	"graphql-backend/models"
 )
 
@@ -10,13 +10,19 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	userRepo *models.UserRepository
-	postRepo *models.PostRepository
+	userRepo    *models.UserRepository
+	postRepo    *models.PostRepository
+	commentRepo *models.CommentRepository
+	tagRepo     *models.TagRepository
+	comments    config.CommentsConfig
 }
 
-func NewResolver(db *sql.DB) *Resolver {
+func NewResolver(userRepo *models.UserRepository, postRepo *models.PostRepository, commentRepo *models.CommentRepository, tagRepo *models.TagRepository, comments config.CommentsConfig) *Resolver {
 	return &Resolver{
-		userRepo: models.NewUserRepository(db),
-		postRepo: models.NewPostRepository(db),
+		userRepo:    userRepo,
+		postRepo:    postRepo,
+		commentRepo: commentRepo,
+		tagRepo:     tagRepo,
+		comments:    comments,
 	}
 }
@@ -8,17 +8,18 @@ import (
 	"context"
 	"fmt"
 	"graphql-backend/graph/model"
+	"graphql-backend/models"
 	"graphql-backend/models/loaders"
 	"strconv"
-	"time"
 )
 
 // CreateUser is the resolver for the createUser field.
 func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUserInput) (*model.User, error) {
-	user, err := r.userRepo.Create(input.Name, input.Email)
+	user, err := r.userRepo.Create(ctx, input.Name, input.Email)
 	if err != nil {
 		return nil, err
 	}
+	loaders.FromContext(ctx).PrimeUser(ctx, user)
 
 	return &model.User{
 		ID:        model.NewUserID(user.ID),
@@ -36,10 +37,11 @@ func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input mode
 		return nil, fmt.Errorf("invalid user ID: %w", err)
 	}
 
-	user, err := r.userRepo.Update(userID, input.Name, input.Email)
+	user, err := r.userRepo.Update(ctx, userID, input.Name, input.Email)
 	if err != nil {
 		return nil, err
 	}
+	loaders.FromContext(ctx).PrimeUser(ctx, user)
 
 	return &model.User{
 		ID:        model.NewUserID(user.ID),
@@ -57,17 +59,18 @@ func (r *mutationResolver) DeleteUser(ctx context.Context, id string) (bool, err
 		return false, fmt.Errorf("invalid user ID: %w", err)
 	}
 
-	err = r.userRepo.Delete(userID)
+	err = r.userRepo.Delete(ctx, userID)
 	if err != nil {
 		return false, err
 	}
+	loaders.FromContext(ctx).ClearUser(ctx, userID)
 
 	return true, nil
 }
 
 // Users is the resolver for the users field.
 func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
-	users, err := r.userRepo.GetAll()
+	users, err := r.userRepo.GetAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +96,7 @@ func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error
 		return nil, fmt.Errorf("invalid user ID: %w", err)
 	}
 
-	user, err := r.userRepo.GetByID(userID)
+	user, err := loaders.FromContext(ctx).UserByID.Load(ctx, userID)()
 	if err != nil {
 		return nil, err
 	}
@@ -108,27 +111,39 @@ func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error
 }
 
 // Posts is the resolver for the posts field.
-func (r *queryResolver) Posts(ctx context.Context) ([]*model.Post, error) {
-	return []*model.Post{
-		{
-			ID:        "1",
-			Title:     "Post 1",
-			Content:   "Content 1",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		},
-	}, nil
+func (r *queryResolver) Posts(ctx context.Context, filter *model.PostFilter) ([]*model.Post, error) {
+	var posts []*models.Post
+	var err error
+	if filter != nil && filter.Tag != nil {
+		posts, err = r.postRepo.GetByTag(ctx, models.NormalizeSlug(*filter.Tag))
+	} else {
+		posts, err = r.postRepo.GetAll(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*model.Post{}
+	for _, post := range posts {
+		result = append(result, newPost(post))
+	}
+
+	return result, nil
 }
 
 // Post is the resolver for the post field.
 func (r *queryResolver) Post(ctx context.Context, id string) (*model.Post, error) {
-	return &model.Post{
-		ID:        "1",
-		Title:     "Post 1",
-		Content:   "Content 1",
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}, nil
+	postID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid post ID: %w", err)
+	}
+
+	post, err := r.postRepo.GetByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPost(post), nil
 }
 
 // Posts is the resolver for the posts field.
@@ -158,6 +173,9 @@ func (r *userResolver) Posts(ctx context.Context, obj *model.User) ([]*model.Pos
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Post returns PostResolver implementation.
+func (r *Resolver) Post() PostResolver { return &postResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
@@ -165,5 +183,6 @@ func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 func (r *Resolver) User() UserResolver { return &userResolver{r} }
 
 type mutationResolver struct{ *Resolver }
+type postResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 type userResolver struct{ *Resolver }
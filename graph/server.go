@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"time"
+
+	"graphql-backend/config"
+	"graphql-backend/graph/batching"
+	"graphql-backend/graph/complexity"
+	"graphql-backend/graph/incremental"
+	"graphql-backend/graph/persisted"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// NewServer builds the GraphQL handler with the same transports as
+// handler.NewDefaultServer, but with the service's own extensions.
+//
+// With an allowlist only queries from the manifest are executed and APQ
+// registration is disabled; otherwise APQ uses apqCache (nil disables it).
+func NewServer(resolver *Resolver, cfg config.GraphQLConfig, apqCache graphql.Cache[string], allowlist *persisted.Manifest) *handler.Server {
+	srv := handler.New(newStreamingSchema(Config{Resolvers: resolver}))
+
+	srv.AddTransport(incremental.Transport{Transport: transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+	}})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	// @defer を含むクエリは Accept: multipart/mixed で段階的に返す
+	srv.AddTransport(incremental.Transport{Transport: transport.MultipartMixed{Boundary: "-"}})
+	if cfg.MaxBatchSize > 0 {
+		srv.AddTransport(batching.Transport{MaxBatchSize: cfg.MaxBatchSize})
+	}
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+
+	srv.SetErrorPresenter(presentError)
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+
+	srv.Use(extension.Introspection{})
+	srv.Use(incremental.Guard{})
+	switch {
+	case allowlist != nil:
+		srv.Use(persisted.Allowlist{Manifest: allowlist})
+	case apqCache != nil:
+		srv.Use(extension.AutomaticPersistedQuery{Cache: apqCache})
+	}
+	srv.Use(complexity.New(cfg))
+
+	return srv
+}
+
+// extendedError is implemented by errors that carry their own GraphQL error
+// extensions, such as loaders.NotFoundError.
+type extendedError interface {
+	Extensions() map[string]any
+}
+
+// presentError copies the extensions of a resolver error (or an error it
+// wraps) into the GraphQL error, so that e.g. its code reaches the client.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var ext extendedError
+	if errors.As(err, &ext) {
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = map[string]any{}
+		}
+		maps.Copy(gqlErr.Extensions, ext.Extensions())
+	}
+	return gqlErr
+}
@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"graphql-backend/graph/model"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// streamingSchema is the generated schema with @stream implemented on top of
+// it: the directive keeps the items after initialCount out of the list and
+// Exec sends each of them, once resolved, in its own payload after those of
+// the executor. A payload carries one item as data, with the item's index
+// at the end of its path.
+type streamingSchema struct {
+	*executableSchema
+}
+
+func newStreamingSchema(cfg Config) *streamingSchema {
+	s := &streamingSchema{}
+	cfg.Directives.Stream = s.stream
+	s.executableSchema = NewExecutableSchema(cfg).(*executableSchema)
+	return s
+}
+
+// streams collects the payloads of the streamed items of one operation.
+type streams struct {
+	// pending counts the payloads that are announced but not yet returned.
+	pending  atomic.Int32
+	payloads chan *graphql.Response
+}
+
+type streamsKey struct{}
+
+func (st *streams) send(ctx context.Context, resp *graphql.Response) {
+	select {
+	case st.payloads <- resp:
+	case <-ctx.Done():
+	}
+}
+
+func (s *streamingSchema) Exec(ctx context.Context) graphql.ResponseHandler {
+	responses := s.executableSchema.Exec(ctx)
+	if graphql.GetOperationContext(ctx).Operation.Operation != ast.Query {
+		return responses
+	}
+
+	st := &streams{payloads: make(chan *graphql.Response)}
+	executing := true
+	return func(ctx context.Context) *graphql.Response {
+		if executing {
+			resp := responses(context.WithValue(ctx, streamsKey{}, st))
+			if resp != nil && resp.HasNext != nil && *resp.HasNext {
+				return resp
+			}
+			executing = false
+			if resp != nil {
+				// 実行中のペイロードで登録されたストリームがあれば続きがある
+				if st.pending.Load() > 0 {
+					hasNext := true
+					resp.HasNext = &hasNext
+				}
+				return resp
+			}
+		}
+		if st.pending.Load() == 0 {
+			return nil
+		}
+		resp := <-st.payloads
+		hasNext := st.pending.Add(-1) > 0
+		resp.HasNext = &hasNext
+		return resp
+	}
+}
+
+// stream implements @stream for lists of posts; other lists, and lists in
+// mutations, are returned in full.
+func (s *streamingSchema) stream(ctx context.Context, obj any, next graphql.Resolver, ifArg *bool, label *string, initialCount *int) (any, error) {
+	res, err := next(ctx)
+	st, ok := ctx.Value(streamsKey{}).(*streams)
+	if err != nil || !ok || (ifArg != nil && !*ifArg) {
+		return res, err
+	}
+	count := 0
+	if initialCount != nil {
+		count = *initialCount
+	}
+	if count < 0 {
+		return nil, fmt.Errorf("initialCount must not be negative, got %d", count)
+	}
+	posts, ok := res.([]*model.Post)
+	if !ok || len(posts) <= count {
+		return res, nil
+	}
+
+	rest := posts[count:]
+	st.pending.Add(int32(len(rest)))
+	sel := graphql.GetFieldContext(ctx).Field.Selections
+	go func() {
+		// 解決は並行に行い（ローダーのバッチもまとまる）、送信はリストの順に行う
+		resolved := make([]chan func(), len(rest))
+		for i, post := range rest {
+			resolved[i] = make(chan func(), 1)
+			go func() {
+				resolved[i] <- s.streamPost(ctx, st, sel, label, count+i, post)
+			}()
+		}
+		for _, ch := range resolved {
+			(<-ch)()
+		}
+	}()
+	return posts[:count], nil
+}
+
+// streamPost resolves the post at index of a streamed list. The returned
+// function sends its payload, followed by those of the fragments it defers.
+func (s *streamingSchema) streamPost(ctx context.Context, st *streams, sel ast.SelectionSet, label *string, index int, post *model.Post) func() {
+	ec := executionContext{graphql.GetOperationContext(ctx), s.executableSchema, 0, 0, make(chan graphql.DeferredResult)}
+	ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{Index: &index, Result: &post})
+	ctx = graphql.WithFreshResponseContext(ctx)
+
+	data := func() (data graphql.Marshaler) {
+		defer func() {
+			if r := recover(); r != nil {
+				ec.Error(ctx, ec.Recover(ctx, r))
+				data = graphql.Null
+			}
+		}()
+		return ec.marshalNPost2ᚖgraphqlᚑbackendᚋgraphᚋmodelᚐPost(ctx, sel, post)
+	}()
+	var buf bytes.Buffer
+	data.MarshalGQL(&buf)
+	resp := &graphql.Response{
+		Data:   buf.Bytes(),
+		Path:   graphql.GetPath(ctx),
+		Errors: graphql.GetErrors(ctx),
+	}
+	if label != nil {
+		resp.Label = *label
+	}
+
+	// 投稿の中の @defer はこの投稿のペイロードの後に送る
+	deferred := atomic.LoadInt32(&ec.deferred)
+	st.pending.Add(deferred)
+	return func() {
+		st.send(ctx, resp)
+		go func() {
+			for received := int32(0); received < deferred; received++ {
+				result := <-ec.deferredResults
+				// 遅延されたフラグメントの中でさらに遅延されたものも数える
+				if n := atomic.LoadInt32(&ec.deferred); n > deferred {
+					st.pending.Add(n - deferred)
+					deferred = n
+				}
+				var buf bytes.Buffer
+				result.Result.MarshalGQL(&buf)
+				st.send(ctx, &graphql.Response{
+					Data:   buf.Bytes(),
+					Path:   result.Path,
+					Label:  result.Label,
+					Errors: result.Errors,
+				})
+			}
+		}()
+	}
+}
@@ -0,0 +1,101 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.78
+
+import (
+	"context"
+	"fmt"
+	"graphql-backend/graph/model"
+	"graphql-backend/models"
+	"graphql-backend/models/loaders"
+	"strconv"
+)
+
+// AttachTags is the resolver for the attachTags field.
+func (r *mutationResolver) AttachTags(ctx context.Context, postID string, tags []string) (*model.Post, error) {
+	id, err := strconv.Atoi(postID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid post ID: %w", err)
+	}
+
+	post, err := r.postRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.tagRepo.Attach(ctx, id, tags)
+	if err != nil {
+		return nil, err
+	}
+	loaders.FromContext(ctx).ClearTagsByPost(ctx, id)
+
+	return newPost(post), nil
+}
+
+// DetachTags is the resolver for the detachTags field.
+func (r *mutationResolver) DetachTags(ctx context.Context, postID string, tags []string) (*model.Post, error) {
+	id, err := strconv.Atoi(postID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid post ID: %w", err)
+	}
+
+	post, err := r.postRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.tagRepo.Detach(ctx, id, tags)
+	if err != nil {
+		return nil, err
+	}
+	loaders.FromContext(ctx).ClearTagsByPost(ctx, id)
+
+	return newPost(post), nil
+}
+
+// Tags is the resolver for the tags field.
+func (r *postResolver) Tags(ctx context.Context, obj *model.Post) ([]*model.Tag, error) {
+	postID, err := strconv.Atoi(obj.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid post ID: %w", err)
+	}
+
+	tags, err := loaders.FromContext(ctx).TagsByPostID.Load(ctx, postID)()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*model.Tag{}
+	for _, tag := range tags {
+		result = append(result, newTag(tag))
+	}
+
+	return result, nil
+}
+
+// Tag is the resolver for the tag field.
+func (r *queryResolver) Tag(ctx context.Context, slug string) (*model.Tag, error) {
+	tag, err := r.tagRepo.GetBySlug(ctx, models.NormalizeSlug(slug))
+	if err != nil || tag == nil {
+		return nil, err
+	}
+
+	return newTag(tag), nil
+}
+
+// Tags is the resolver for the tags field.
+func (r *queryResolver) Tags(ctx context.Context) ([]*model.Tag, error) {
+	tags, err := r.tagRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*model.Tag{}
+	for _, tag := range tags {
+		result = append(result, newTag(tag))
+	}
+
+	return result, nil
+}
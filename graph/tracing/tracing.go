@@ -0,0 +1,129 @@
+// Package tracing records a span for every GraphQL operation and, sampled,
+// for the resolvers it runs.
+package tracing
+
+import (
+	"context"
+	"math/rand/v2"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("graphql-backend/graph")
+
+// Extension is a gqlgen extension creating the spans. Resolver spans are
+// only started for a ResolverSampleRatio share of resolver calls, since a
+// list query can run thousands of them.
+type Extension struct {
+	ResolverSampleRatio float64
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Extension{}
+
+func (e Extension) ExtensionName() string {
+	return "Tracing"
+}
+
+func (e Extension) Validate(schema graphql.ExecutableSchema) error {
+	return nil
+}
+
+type operationSpanKey struct{}
+
+// InterceptOperation starts the operation's span once, so that an operation
+// delivered in several payloads (@defer) gets one span covering all of them
+// and its deferred resolvers are traced as its children. Subscriptions keep
+// a span per event, started in InterceptResponse.
+func (e Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	opCtx := graphql.GetOperationContext(ctx)
+	if opCtx.Operation == nil || opCtx.Operation.Operation == ast.Subscription {
+		return next(ctx)
+	}
+
+	_, span := startOperation(ctx, opCtx)
+	responses := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		ctx = trace.ContextWithSpan(ctx, span)
+		return responses(context.WithValue(ctx, operationSpanKey{}, span))
+	}
+}
+
+func (e Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	span, ok := ctx.Value(operationSpanKey{}).(trace.Span)
+	if !ok {
+		// 実行前に弾かれたエラーとサブスクリプションのイベントはここで span を作る
+		ctx, span = startOperation(ctx, graphql.GetOperationContext(ctx))
+	}
+
+	resp := next(ctx)
+	if resp != nil && len(resp.Errors) > 0 {
+		span.SetStatus(codes.Error, resp.Errors.Error())
+	}
+	if resp == nil || resp.HasNext == nil || !*resp.HasNext {
+		span.End()
+	}
+	return resp
+}
+
+func startOperation(ctx context.Context, opCtx *graphql.OperationContext) (context.Context, trace.Span) {
+	name, opType := opCtx.OperationName, ""
+	if opCtx.Operation != nil {
+		opType = string(opCtx.Operation.Operation)
+		if opCtx.Operation.Name != "" {
+			name = opCtx.Operation.Name
+		}
+	}
+
+	return tracer.Start(ctx, "graphql."+opType+" "+name,
+		trace.WithTimestamp(opCtx.Stats.OperationStart),
+		trace.WithAttributes(
+			attribute.String("graphql.operation.name", name),
+			attribute.String("graphql.operation.type", opType),
+		),
+	)
+}
+
+func (e Extension) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fc := graphql.GetFieldContext(ctx)
+	if fc == nil || !fc.IsResolver {
+		return next(ctx)
+	}
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		// deferred resolvers run after their parent resolver's span has ended;
+		// attach them to the operation span instead
+		op, ok := ctx.Value(operationSpanKey{}).(trace.Span)
+		if !ok || !op.IsRecording() {
+			return next(ctx)
+		}
+		ctx = trace.ContextWithSpan(ctx, op)
+	}
+	if rand.Float64() >= e.ResolverSampleRatio {
+		return next(ctx)
+	}
+
+	ctx, span := tracer.Start(ctx, fc.Object+"."+fc.Field.Name, trace.WithAttributes(
+		attribute.String("graphql.field.path", fc.Path().String()),
+	))
+	defer span.End()
+
+	res, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return res, err
+}
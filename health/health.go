@@ -0,0 +1,123 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check is a named probe run by the readiness endpoint.
+type Check struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a single Check.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Response is the body returned by the health endpoints.
+type Response struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+var started = time.Now()
+
+// Liveness reports that the process is up and serving HTTP. It deliberately
+// has no dependencies so that a database outage does not get the pod killed.
+func Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		writeJSON(w, http.StatusOK, map[string]any{
+			"status":         StatusOK,
+			"uptime_seconds": int64(time.Since(started).Seconds()),
+			"checks": map[string]CheckResult{
+				"process": {Status: StatusOK, LatencyMs: latencyMs(time.Since(start))},
+			},
+		})
+	})
+}
+
+// Readiness runs every check concurrently, each bounded by timeout, and
+// answers 503 if any of them fails.
+func Readiness(timeout time.Duration, checks ...Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := Run(r.Context(), timeout, checks...)
+
+		code := http.StatusOK
+		if resp.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, resp)
+	})
+}
+
+// Run executes the checks and aggregates their results. It returns once
+// every check has finished or timeout has passed, whichever comes first;
+// checks still running then are reported as failed.
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) Response {
+	resp := Response{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type namedResult struct {
+		name   string
+		result CheckResult
+	}
+	// バッファ付きなので、タイムアウト後に終わったチェックもブロックしない
+	results := make(chan namedResult, len(checks))
+	start := time.Now()
+	for _, check := range checks {
+		go func() {
+			err := check.Check(ctx)
+			result := CheckResult{Status: StatusOK, LatencyMs: latencyMs(time.Since(start))}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+			results <- namedResult{check.Name, result}
+		}()
+	}
+
+	for range checks {
+		select {
+		case r := <-results:
+			resp.Checks[r.name] = r.result
+			if r.result.Status != StatusOK {
+				resp.Status = StatusFail
+			}
+		case <-ctx.Done():
+			for _, check := range checks {
+				if _, ok := resp.Checks[check.Name]; !ok {
+					resp.Checks[check.Name] = CheckResult{Status: StatusFail, LatencyMs: latencyMs(time.Since(start)), Error: ctx.Err().Error()}
+				}
+			}
+			resp.Status = StatusFail
+			return resp
+		}
+	}
+
+	return resp
+}
+
+func latencyMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
@@ -1,58 +1,211 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"graphql-backend/cache"
+	"graphql-backend/config"
 	"graphql-backend/database"
 	"graphql-backend/graph"
+	"graphql-backend/graph/cachecontrol"
+	"graphql-backend/graph/logging"
+	"graphql-backend/graph/metrics"
+	"graphql-backend/graph/persisted"
+	"graphql-backend/graph/ratelimit"
+	"graphql-backend/graph/tracing"
+	"graphql-backend/health"
+	"graphql-backend/migrations"
 	"graphql-backend/models"
 	"graphql-backend/models/loaders"
+	"graphql-backend/server"
+	"graphql-backend/telemetry"
 
-	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/cors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
-const defaultPort = "8080"
-
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	var (
+		configPath  = flag.String("config", "", "Path to a YAML or TOML config file (default: $CONFIG_FILE)")
+		printConfig = flag.Bool("print-config", false, "Print the resolved configuration with secrets redacted and exit")
+	)
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+	if *printConfig {
+		fmt.Print(cfg)
+		return
 	}
+	port := cfg.Server.Port
 
-	db, err := database.NewDB()
+	logger := logging.NewLogger(cfg.Logging)
+	slog.SetDefault(logger)
+
+	// 起動に失敗したときも、他の defer（DB のクローズ・トレースの送信）を済ませてから 1 で終了する
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
+	db, err := database.NewDB(cfg.Database)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 	defer db.Close()
 
-	resolver := graph.NewResolver(db.DB)
-	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go db.ReportStats(ctx, cfg.Database.StatsInterval.Duration)
+
+	shutdownTracing, err := telemetry.SetupTracing(ctx, cfg.Tracing)
+	if err != nil {
+		log.Fatal("Failed to set up tracing:", err)
+	}
+	defer func() {
+		// シャットダウン後もバッファ済みのスパンを送り切る
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("Failed to flush traces: %v", err)
+		}
+	}()
+
+	// 2 段目のキャッシュ（リクエストをまたいで共有、リポジトリの書き込みで無効化）
+	var store cache.Store
+	if cfg.DataLoader.Shared.Backend == "memory" {
+		store, err = cache.NewLRU(cfg.DataLoader.Shared.Size)
+		if err != nil {
+			log.Fatal("Failed to create shared cache:", err)
+		}
+	}
+	userRepo := models.NewUserRepository(db, store)
+	postRepo := models.NewPostRepository(db, store)
+	commentRepo := models.NewCommentRepository(db)
+	tagRepo := models.NewTagRepository(db)
+
+	resolver := graph.NewResolver(userRepo, postRepo, commentRepo, tagRepo, cfg.Comments)
+
+	var apqCache graphql.Cache[string]
+	switch pq := cfg.GraphQL.PersistedQueries; pq.Cache {
+	case "memory":
+		apqCache = lru.New[string](pq.CacheSize)
+	case "mysql":
+		apqCache = persisted.NewMySQLCache(db, pq.CacheSize, pq.MaxStored)
+	}
+
+	// マニフェストは許可リストのほか、メトリクスのオペレーション名にも使う
+	var manifest, allowlist *persisted.Manifest
+	if path := cfg.GraphQL.PersistedQueries.Manifest; path != "" {
+		manifest, err = persisted.LoadManifest(path)
+		if err != nil {
+			log.Fatal("Failed to load persisted query manifest:", err)
+		}
+	}
+	if cfg.AllowlistOnly() {
+		allowlist = manifest
+		log.Printf("Persisted query allowlist enabled with %d queries", allowlist.Len())
+	}
+
+	srv := graph.NewServer(resolver, cfg.GraphQL, apqCache, allowlist)
+	srv.Use(tracing.Extension{ResolverSampleRatio: cfg.Tracing.ResolverSampleRatio})
+	srv.Use(logging.New(logger, cfg.Logging))
+	srv.Use(cachecontrol.New())
+	if cfg.Metrics.Enabled {
+		var operations []string
+		if manifest != nil {
+			operations = manifest.OperationNames()
+		}
+		srv.Use(metrics.New(operations))
+	}
+	if cfg.RateLimit.Enabled {
+		limiter := ratelimit.New(cfg.RateLimit)
+		go limiter.Prune(ctx)
+		srv.Use(limiter)
+	}
 
 	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"*"},
-		AllowCredentials: true,
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
 	})
 
 	// --- ここがポイント ---
-	// 1) まず CORS で包む
-	corsWrapped := c.Handler(srv)
-
-	postRepo := models.NewPostRepository(db.DB)
+	// 1) まず CORS で包む（GET クエリには Cache-Control と ETag を付ける）
+	corsWrapped := c.Handler(cachecontrol.Middleware(srv))
 
 	// 2) その上から DataLoader ミドルウェアで包む（リクエストごとにLoadersを注入）
 	//    NewLoaders に必要な依存（repo/DB等）を渡してください
-	loaderWrapped := loaders.Middleware(postRepo)(corsWrapped)
-	
+	loaderWrapped := loaders.Middleware(userRepo, postRepo, commentRepo, tagRepo, store, cfg.DataLoader)(corsWrapped)
+
+	// 3) レート制限のためにクライアント（ユーザー / 検証済み API キー / IP）を識別する
+	limitWrapped := ratelimit.Middleware(cfg.RateLimit)(loaderWrapped)
 
-	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	http.Handle("/query", loaderWrapped)
+	// 4) DB セッションで包む（ミューテーション後の読み込みはプライマリへ）
+	sessionWrapped := database.Middleware(limitWrapped)
+
+	// 5) リクエスト ID を振り、SQL の実行回数を数え始める
+	loggingWrapped := logging.Middleware(sessionWrapped)
+
+	// 6) 最後に HTTP リクエスト全体のスパンを張る
+	tracedWrapped := otelhttp.NewHandler(loggingWrapped, "/query")
+
+	mux := http.NewServeMux()
+	mux.Handle("/", playground.Handler("GraphQL playground", "/query"))
+	mux.Handle("/query", tracedWrapped)
+
+	// マイグレーションファイルは起動時に一度だけ読み込む（/readyz のたびに読まない）
+	migrator := migrations.NewMigrator(db.DB)
+	migrationSet, migrationsErr := migrator.LoadMigrations(cfg.Migrations.Dir)
+	if migrationsErr != nil {
+		log.Printf("Warning: failed to load migrations, /readyz will fail: %v", migrationsErr)
+	}
+	mux.Handle("/healthz", health.Liveness())
+	mux.Handle("/readyz", health.Readiness(cfg.Health.CheckTimeout.Duration,
+		health.Check{Name: "database", Check: db.PingContext},
+		health.Check{Name: "migrations", Check: func(ctx context.Context) error {
+			if migrationsErr != nil {
+				return migrationsErr
+			}
+			pending, err := migrator.Pending(ctx, migrationSet)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("%d pending migrations, next is %d", len(pending), pending[0].Version)
+			}
+			return nil
+		}},
+	))
+	mux.Handle("/version", health.VersionHandler())
+	if cfg.Metrics.Enabled {
+		prometheus.MustRegister(db.Collectors()...)
+		mux.Handle(cfg.Metrics.Path, telemetry.MetricsHandler())
+	}
 
 	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
-}
\ No newline at end of file
+	if err := server.Run(ctx, cfg.Server, mux); err != nil {
+		log.Printf("Server error: %v", err)
+		exitCode = 1
+	}
+	// ここで defer の db.Close() が走り、コネクションプールを閉じる
+}
@@ -1,15 +1,11 @@
 -- +migrate Up
--- Add your up migration here
-
-INSERT INTO users (name, email) VALUES
-  ('John Doe', 'john@example.com'),
-  ('Jane Smith', 'jane@example.com'),
-  ('Bob Johnson', 'bob@example.com');
-
-
+-- This migration used to insert the demo users. It has already been applied
+-- to existing databases, so it is kept as a no-op; the data now lives in the
+-- seeds package (make seed).
 
+SELECT 1;
 
 -- +migrate Down
--- Add your down migration here
+-- Nothing to undo: seeded demo users are not owned by the migration history.
 
-DELETE FROM users;
\ No newline at end of file
+SELECT 1;
@@ -0,0 +1,10 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS persisted_queries (
+    hash CHAR(64) PRIMARY KEY,
+    query MEDIUMTEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_persisted_queries_created_at (created_at)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS persisted_queries;
@@ -0,0 +1,209 @@
+package migrations
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DiffSchemas returns the statements that turn from into to. The reverse
+// migration is DiffSchemas(to, from).
+//
+// Foreign keys are dropped first and added last so that tables can be created
+// and dropped in any order.
+func DiffSchemas(from, to *Schema) []string {
+	var statements []string
+
+	for _, ft := range from.Tables {
+		tt := to.table(ft.Name)
+		for _, fk := range ft.ForeignKeys {
+			if tt == nil || !reflect.DeepEqual(tt.foreignKey(fk.Name), &fk) {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", quoteIdent(ft.Name), quoteIdent(fk.Name)))
+			}
+		}
+	}
+
+	for _, ft := range from.Tables {
+		if to.table(ft.Name) == nil {
+			statements = append(statements, fmt.Sprintf("DROP TABLE %s", quoteIdent(ft.Name)))
+		}
+	}
+
+	for _, tt := range to.Tables {
+		if from.table(tt.Name) == nil {
+			statements = append(statements, createTable(tt))
+		}
+	}
+
+	for _, tt := range to.Tables {
+		if ft := from.table(tt.Name); ft != nil {
+			statements = append(statements, alterTable(ft, &tt)...)
+		}
+	}
+
+	for _, tt := range to.Tables {
+		ft := from.table(tt.Name)
+		for _, fk := range tt.ForeignKeys {
+			if ft == nil || !reflect.DeepEqual(ft.foreignKey(fk.Name), &fk) {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD %s", quoteIdent(tt.Name), foreignKeyDefinition(fk)))
+			}
+		}
+	}
+
+	return statements
+}
+
+// FormatStatements renders statements as a migration section.
+func FormatStatements(statements []string) string {
+	var b strings.Builder
+	for i, statement := range statements {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(statement)
+		b.WriteString(";")
+	}
+	return b.String()
+}
+
+func alterTable(from, to *Table) []string {
+	var statements []string
+	table := quoteIdent(to.Name)
+
+	for _, index := range from.Indexes {
+		if !reflect.DeepEqual(to.index(index.Name), &index) {
+			statements = append(statements, dropIndex(to.Name, index))
+		}
+	}
+
+	for _, column := range from.Columns {
+		if to.column(column.Name) == nil {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, quoteIdent(column.Name)))
+		}
+	}
+
+	for i, column := range to.Columns {
+		old := from.column(column.Name)
+		switch {
+		case old == nil:
+			position := "FIRST"
+			if i > 0 {
+				position = "AFTER " + quoteIdent(to.Columns[i-1].Name)
+			}
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, columnDefinition(column), position))
+		case !reflect.DeepEqual(*old, column):
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", table, columnDefinition(column)))
+		}
+	}
+
+	for _, index := range to.Indexes {
+		if !reflect.DeepEqual(from.index(index.Name), &index) {
+			statements = append(statements, addIndex(to.Name, index))
+		}
+	}
+
+	return statements
+}
+
+func createTable(t Table) string {
+	var lines []string
+	for _, column := range t.Columns {
+		lines = append(lines, "    "+columnDefinition(column))
+	}
+	for _, index := range t.Indexes {
+		lines = append(lines, "    "+indexDefinition(index))
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n)", quoteIdent(t.Name), strings.Join(lines, ",\n"))
+}
+
+func columnDefinition(c Column) string {
+	parts := []string{quoteIdent(c.Name), c.Type}
+	if c.Nullable {
+		parts = append(parts, "NULL")
+	} else {
+		parts = append(parts, "NOT NULL")
+	}
+	if c.Default != nil {
+		if c.Expression {
+			parts = append(parts, "DEFAULT "+*c.Default)
+		} else {
+			parts = append(parts, "DEFAULT "+quoteString(*c.Default))
+		}
+	}
+	if c.Extra != "" {
+		parts = append(parts, strings.ToUpper(c.Extra))
+	}
+	return strings.Join(parts, " ")
+}
+
+func indexDefinition(index Index) string {
+	columns := quoteIdents(index.Columns)
+	switch {
+	case index.Name == "PRIMARY":
+		return fmt.Sprintf("PRIMARY KEY (%s)", columns)
+	case index.Unique:
+		return fmt.Sprintf("UNIQUE KEY %s (%s)", quoteIdent(index.Name), columns)
+	default:
+		return fmt.Sprintf("KEY %s (%s)", quoteIdent(index.Name), columns)
+	}
+}
+
+func addIndex(table string, index Index) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD %s", quoteIdent(table), indexDefinition(index))
+}
+
+func dropIndex(table string, index Index) string {
+	if index.Name == "PRIMARY" {
+		return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY", quoteIdent(table))
+	}
+	return fmt.Sprintf("ALTER TABLE %s DROP INDEX %s", quoteIdent(table), quoteIdent(index.Name))
+}
+
+func foreignKeyDefinition(fk ForeignKey) string {
+	return fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s) ON UPDATE %s ON DELETE %s",
+		quoteIdent(fk.Name), quoteIdents(fk.Columns), quoteIdent(fk.ReferencedTable), quoteIdents(fk.ReferencedColumns),
+		fk.OnUpdate, fk.OnDelete)
+}
+
+func (t *Table) column(name string) *Column {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+func (t *Table) index(name string) *Index {
+	for i := range t.Indexes {
+		if t.Indexes[i].Name == name {
+			return &t.Indexes[i]
+		}
+	}
+	return nil
+}
+
+func (t *Table) foreignKey(name string) *ForeignKey {
+	for i := range t.ForeignKeys {
+		if t.ForeignKeys[i].Name == name {
+			return &t.ForeignKeys[i]
+		}
+	}
+	return nil
+}
+
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func quoteIdents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// GoMigrationFunc is a migration step written in Go. It runs inside a
+// transaction that also records the migration, so returning an error leaves
+// both the data and the migrations table untouched.
+type GoMigrationFunc func(ctx context.Context, tx *sql.Tx) error
+
+var (
+	goMigrationsMu sync.Mutex
+	goMigrations   = map[int64]Migration{}
+)
+
+// Register adds a Go migration to the plan built by LoadMigrations. It is
+// meant to be called from an init function in a file placed next to the .sql
+// migrations, e.g. migrations/20250910090000_normalize_emails.go:
+//
+//	func init() {
+//		Register(20250910090000, "normalize_emails", upNormalizeEmails, nil)
+//	}
+//
+// down may be nil when the migration cannot be reverted.
+func Register(version int64, name string, up, down GoMigrationFunc) {
+	if up == nil {
+		panic(fmt.Sprintf("migrations: Go migration %d has no up function", version))
+	}
+
+	goMigrationsMu.Lock()
+	defer goMigrationsMu.Unlock()
+
+	if _, ok := goMigrations[version]; ok {
+		panic(fmt.Sprintf("migrations: Go migration %d registered twice", version))
+	}
+
+	goMigrations[version] = Migration{
+		Version: version,
+		Name:    name,
+		Up:      up,
+		Down:    down,
+	}
+}
+
+func registeredGoMigrations() []Migration {
+	goMigrationsMu.Lock()
+	defer goMigrationsMu.Unlock()
+
+	migrations := make([]Migration, 0, len(goMigrations))
+	for _, migration := range goMigrations {
+		migrations = append(migrations, migration)
+	}
+	return migrations
+}
+
+// runGo executes fn and the bookkeeping step in a single transaction.
+func (m *Migrator) runGo(fn GoMigrationFunc, bookkeeping GoMigrationFunc) error {
+	ctx := context.Background()
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := bookkeeping(ctx, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -1,6 +1,8 @@
 package migrations
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
 	"fmt"
 	"io/ioutil"
@@ -11,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 type Migration struct {
@@ -18,22 +21,51 @@ type Migration struct {
 	Name    string
 	UpSQL   string
 	DownSQL string
+	// Checksum is the SHA-256 of the migration file; empty for Go migrations.
+	Checksum string
+
+	// Up and Down are set for migrations registered with Register instead of
+	// being read from a .sql file.
+	Up   GoMigrationFunc
+	Down GoMigrationFunc
+}
+
+// IsGo reports whether the migration is implemented in Go.
+func (m Migration) IsGo() bool {
+	return m.Up != nil
+}
+
+func (m Migration) hasDown() bool {
+	if m.IsGo() {
+		return m.Down != nil
+	}
+	return m.DownSQL != ""
 }
 
 type Migrator struct {
-	db *sql.DB
+	db     *sql.DB
+	dryRun bool
 }
 
 func NewMigrator(db *sql.DB) *Migrator {
 	return &Migrator{db: db}
 }
 
+// SetDryRun makes Up, Down, Goto and Redo print the SQL they would execute to
+// stdout instead of running it. The database is only read, never written.
+func (m *Migrator) SetDryRun(dryRun bool) {
+	m.dryRun = dryRun
+}
+
 func (m *Migrator) CreateMigrationsTable() error {
 	query := `
 	CREATE TABLE IF NOT EXISTS migrations (
 		version BIGINT PRIMARY KEY,
 		name VARCHAR(255) NOT NULL,
-		executed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		executed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		checksum VARCHAR(64) NOT NULL DEFAULT '',
+		duration_ms BIGINT NOT NULL DEFAULT 0,
+		applied_by VARCHAR(255) NOT NULL DEFAULT ''
 	)`
 	
 	_, err := m.db.Exec(query)
@@ -41,7 +73,7 @@ func (m *Migrator) CreateMigrationsTable() error {
 		return fmt.Errorf("failed to create migrations table: %w", err)
 	}
 	
-	return nil
+	return m.upgradeMigrationsTable()
 }
 
 func (m *Migrator) GetAppliedMigrations() (map[int64]bool, error) {
@@ -99,12 +131,24 @@ func (m *Migrator) LoadMigrations(migrationsDir string) ([]Migration, error) {
 		upSQL, downSQL := parseMigrationContent(sqlContent)
 		
 		migrations = append(migrations, Migration{
-			Version: version,
-			Name:    name,
-			UpSQL:   upSQL,
-			DownSQL: downSQL,
+			Version:  version,
+			Name:     name,
+			UpSQL:    upSQL,
+			DownSQL:  downSQL,
+			Checksum: fmt.Sprintf("%x", sha256.Sum256(content)),
 		})
 	}
+
+	seen := make(map[int64]bool, len(migrations))
+	for _, migration := range migrations {
+		seen[migration.Version] = true
+	}
+	for _, migration := range registeredGoMigrations() {
+		if seen[migration.Version] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
 	
 	sort.Slice(migrations, func(i, j int) bool {
 		return migrations[i].Version < migrations[j].Version
@@ -125,17 +169,90 @@ func parseMigrationContent(content string) (string, string) {
 	return upSQL, downSQL
 }
 
-func (m *Migrator) Up(migrationsDir string) error {
-	if err := m.CreateMigrationsTable(); err != nil {
-		return err
+// execScript runs each statement of a migration section in turn, since the
+// driver does not accept multiple statements in a single Exec.
+func (m *Migrator) execScript(script string) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := m.db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitStatements splits a script on the semicolons that end statements,
+// i.e. those outside of quoted strings, quoted identifiers and comments
+// (--, # and /* */). Statements consisting only of comments are dropped.
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	var quote rune
+	hasCode := false
+
+	flush := func() {
+		if hasCode {
+			statements = append(statements, strings.TrimSpace(current.String()))
+		}
+		current.Reset()
+		hasCode = false
 	}
 	
-	migrations, err := m.LoadMigrations(migrationsDir)
-	if err != nil {
-		return err
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		if quote != 0 {
+			current.WriteRune(r)
+			// '' のような二重引用符は、閉じてすぐ開き直すのと同じ扱いになる
+			if r == '\\' && quote != '`' && i+1 < len(runes) {
+				i++
+				current.WriteRune(runes[i])
+			} else if r == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch {
+		case r == '#' || r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				current.WriteRune(runes[i])
+				i++
+			}
+			if i < len(runes) {
+				current.WriteRune('\n')
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			current.WriteString("/*")
+			i += 2
+			for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+				current.WriteRune(runes[i])
+				i++
+			}
+			if i < len(runes) {
+				current.WriteString("*/")
+				i++
+			}
+		case r == ';':
+			flush()
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+			hasCode = true
+			current.WriteRune(r)
+		default:
+			if !unicode.IsSpace(r) {
+				hasCode = true
+			}
+			current.WriteRune(r)
+		}
 	}
+	flush()
 	
-	applied, err := m.GetAppliedMigrations()
+	return statements
+}
+
+func (m *Migrator) Up(migrationsDir string) error {
+	migrations, applied, err := m.prepare(migrationsDir)
 	if err != nil {
 		return err
 	}
@@ -145,17 +262,9 @@ func (m *Migrator) Up(migrationsDir string) error {
 			continue
 		}
 		
-		log.Printf("Applying migration %d: %s", migration.Version, migration.Name)
-		
-		if _, err := m.db.Exec(migration.UpSQL); err != nil {
-			return fmt.Errorf("failed to apply migration %d: %w", migration.Version, err)
-		}
-		
-		if _, err := m.db.Exec("INSERT INTO migrations (version, name) VALUES (?, ?)", migration.Version, migration.Name); err != nil {
-			return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		if err := m.apply(migration); err != nil {
+			return err
 		}
-		
-		log.Printf("Migration %d applied successfully", migration.Version)
 	}
 	
 	log.Println("All migrations applied successfully")
@@ -163,16 +272,7 @@ func (m *Migrator) Up(migrationsDir string) error {
 }
 
 func (m *Migrator) Down(migrationsDir string, steps int) error {
-	if err := m.CreateMigrationsTable(); err != nil {
-		return err
-	}
-	
-	migrations, err := m.LoadMigrations(migrationsDir)
-	if err != nil {
-		return err
-	}
-	
-	applied, err := m.GetAppliedMigrations()
+	migrations, applied, err := m.prepare(migrationsDir)
 	if err != nil {
 		return err
 	}
@@ -188,82 +288,281 @@ func (m *Migrator) Down(migrationsDir string, steps int) error {
 	}
 	
 	for _, migration := range toRollback {
-		if migration.DownSQL == "" {
+		if !migration.hasDown() {
 			log.Printf("Warning: No down migration for %d: %s", migration.Version, migration.Name)
 			continue
 		}
-		
-		log.Printf("Rolling back migration %d: %s", migration.Version, migration.Name)
-		
-		if _, err := m.db.Exec(migration.DownSQL); err != nil {
-			return fmt.Errorf("failed to rollback migration %d: %w", migration.Version, err)
-		}
-		
-		if _, err := m.db.Exec("DELETE FROM migrations WHERE version = ?", migration.Version); err != nil {
-			return fmt.Errorf("failed to remove migration record %d: %w", migration.Version, err)
+		if err := m.rollback(migration); err != nil {
+			return err
 		}
-		
-		log.Printf("Migration %d rolled back successfully", migration.Version)
 	}
 	
 	log.Printf("Rolled back %d migrations successfully", len(toRollback))
 	return nil
 }
 
-func (m *Migrator) Status(migrationsDir string) error {
-	if err := m.CreateMigrationsTable(); err != nil {
+// Goto moves the schema up or down until exactly the migrations with a
+// version <= target are applied. A target of 0 rolls back everything.
+func (m *Migrator) Goto(migrationsDir string, target int64) error {
+	migrations, applied, err := m.prepare(migrationsDir)
+	if err != nil {
+		return err
+	}
+
+	if target != 0 && !containsVersion(migrations, target) {
+		return fmt.Errorf("migration %d not found", target)
+	}
+
+	// 新しい方から順にロールバックしてから、古い方から順に適用する
+	var toRollback []Migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if migration.Version > target && applied[migration.Version] {
+			toRollback = append(toRollback, migration)
+		}
+	}
+	if err := requireDown(toRollback); err != nil {
+		return err
+	}
+
+	for _, migration := range toRollback {
+		if err := m.rollback(migration); err != nil {
+			return err
+		}
+	}
+
+	for _, migration := range migrations {
+		if migration.Version > target || applied[migration.Version] {
+			continue
+		}
+		if err := m.apply(migration); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Migrated to version %d successfully", target)
+	return nil
+}
+
+// Redo rolls back the most recently applied migration and applies it again.
+func (m *Migrator) Redo(migrationsDir string) error {
+	migrations, applied, err := m.prepare(migrationsDir)
+	if err != nil {
 		return err
 	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if !applied[migration.Version] {
+			continue
+		}
+
+		if !migration.hasDown() {
+			return fmt.Errorf("migration %d has no down migration, cannot redo", migration.Version)
+		}
+		if err := m.rollback(migration); err != nil {
+			return err
+		}
+		if err := m.apply(migration); err != nil {
+			return err
+		}
+
+		log.Printf("Migration %d redone successfully", migration.Version)
+		return nil
+	}
+
+	log.Println("No applied migrations to redo")
+	return nil
+}
+
+// prepare ensures the migrations table exists and returns the migrations on
+// disk together with the set of applied versions. In dry-run mode the table is
+// never created; a missing table is treated as "nothing applied".
+func (m *Migrator) prepare(migrationsDir string) ([]Migration, map[int64]bool, error) {
+	if m.dryRun {
+		exists, err := m.migrationsTableExists()
+		if err != nil {
+			return nil, nil, err
+		}
+		migrations, err := m.LoadMigrations(migrationsDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !exists {
+			return migrations, map[int64]bool{}, nil
+		}
+		applied, err := m.GetAppliedMigrations()
+		if err != nil {
+			return nil, nil, err
+		}
+		return migrations, applied, nil
+	}
+
+	if err := m.CreateMigrationsTable(); err != nil {
+		return nil, nil, err
+	}
 	
 	migrations, err := m.LoadMigrations(migrationsDir)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	
 	applied, err := m.GetAppliedMigrations()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	
-	fmt.Println("Migration Status:")
-	fmt.Println("================")
+	return migrations, applied, nil
+}
+
+func (m *Migrator) migrationsTableExists() (bool, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'migrations'"
+	if err := m.db.QueryRow(query).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check migrations table: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	if m.dryRun {
+		fmt.Printf("-- Apply migration %d: %s\n", migration.Version, migration.Name)
+		if migration.IsGo() {
+			fmt.Println("-- (Go migration, SQL is generated at runtime)")
+		} else {
+			fmt.Println(terminate(migration.UpSQL))
+		}
+		fmt.Printf("INSERT INTO migrations (version, name, checksum, duration_ms, applied_by) VALUES (%d, '%s', '%s', 0, '%s');\n\n",
+			migration.Version, migration.Name, migration.Checksum, appliedBy())
+		return nil
+	}
 	
-	if len(migrations) == 0 {
-		fmt.Println("No migrations found")
+	log.Printf("Applying migration %d: %s", migration.Version, migration.Name)
+
+	start := time.Now()
+
+	if migration.IsGo() {
+		err := m.runGo(migration.Up, func(ctx context.Context, tx *sql.Tx) error {
+			return recordMigration(ctx, tx, migration, time.Since(start))
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d: %w", migration.Version, err)
+		}
+		log.Printf("Migration %d applied successfully", migration.Version)
 		return nil
 	}
 	
+	if err := m.execScript(migration.UpSQL); err != nil {
+		return fmt.Errorf("failed to apply migration %d: %w", migration.Version, err)
+	}
+
+	if err := recordMigration(context.Background(), m.db, migration, time.Since(start)); err != nil {
+		return fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+	}
+
+	log.Printf("Migration %d applied successfully", migration.Version)
+	return nil
+}
+
+func (m *Migrator) rollback(migration Migration) error {
+	if !migration.hasDown() {
+		return fmt.Errorf("migration %d (%s) has no down migration, cannot roll back", migration.Version, migration.Name)
+	}
+
+	if m.dryRun {
+		fmt.Printf("-- Roll back migration %d: %s\n", migration.Version, migration.Name)
+		if migration.IsGo() {
+			fmt.Println("-- (Go migration, SQL is generated at runtime)")
+		} else {
+			fmt.Println(terminate(migration.DownSQL))
+		}
+		fmt.Printf("DELETE FROM migrations WHERE version = %d;\n\n", migration.Version)
+		return nil
+	}
+
+	log.Printf("Rolling back migration %d: %s", migration.Version, migration.Name)
+
+	if migration.IsGo() {
+		err := m.runGo(migration.Down, func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM migrations WHERE version = ?", migration.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to rollback migration %d: %w", migration.Version, err)
+		}
+		log.Printf("Migration %d rolled back successfully", migration.Version)
+		return nil
+	}
+
+	if err := m.execScript(migration.DownSQL); err != nil {
+		return fmt.Errorf("failed to rollback migration %d: %w", migration.Version, err)
+	}
+
+	if _, err := m.db.Exec("DELETE FROM migrations WHERE version = ?", migration.Version); err != nil {
+		return fmt.Errorf("failed to remove migration record %d: %w", migration.Version, err)
+	}
+
+	log.Printf("Migration %d rolled back successfully", migration.Version)
+	return nil
+}
+
+// requireDown fails before anything is rolled back if one of migrations
+// cannot be, so that Goto does not stop halfway.
+func requireDown(migrations []Migration) error {
 	for _, migration := range migrations {
-		status := "Pending"
-		if applied[migration.Version] {
-			status = "Applied"
+		if !migration.hasDown() {
+			return fmt.Errorf("migration %d (%s) has no down migration, cannot roll back", migration.Version, migration.Name)
 		}
-		fmt.Printf("%d\t%s\t%s\n", migration.Version, status, migration.Name)
 	}
-	
 	return nil
 }
 
-func CreateMigration(migrationsDir, name string) error {
+func containsVersion(migrations []Migration, version int64) bool {
+	for _, migration := range migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// terminate makes sure a printed statement ends with a semicolon.
+func terminate(query string) string {
+	if strings.HasSuffix(query, ";") {
+		return query
+	}
+	return query + ";"
+}
+
+// CreateMigration writes a new timestamped migration file. Empty upSQL or
+// downSQL sections are filled with a placeholder comment.
+func CreateMigration(migrationsDir, name, upSQL, downSQL string) (string, error) {
 	if err := os.MkdirAll(migrationsDir, 0755); err != nil {
-		return fmt.Errorf("failed to create migrations directory: %w", err)
+		return "", fmt.Errorf("failed to create migrations directory: %w", err)
 	}
 	
 	timestamp := time.Now().Format("20060102150405")
 	filename := fmt.Sprintf("%s_%s.sql", timestamp, strings.ReplaceAll(name, " ", "_"))
 	filepath := filepath.Join(migrationsDir, filename)
 	
+	if upSQL == "" {
+		upSQL = "-- Add your up migration here"
+	}
+	if downSQL == "" {
+		downSQL = "-- Add your down migration here"
+	}
+
 	content := fmt.Sprintf(`-- +migrate Up
--- Add your up migration here
+%s
 
 -- +migrate Down
--- Add your down migration here
-`)
+%s
+`, upSQL, downSQL)
 	
 	if err := ioutil.WriteFile(filepath, []byte(content), 0644); err != nil {
-		return fmt.Errorf("failed to create migration file: %w", err)
+		return "", fmt.Errorf("failed to create migration file: %w", err)
 	}
 	
 	log.Printf("Migration created: %s", filepath)
-	return nil
-}
\ No newline at end of file
+	return filepath, nil
+}
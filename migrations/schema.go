@@ -0,0 +1,282 @@
+package migrations
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Schema is a canonical snapshot of the database structure. Tables, indexes
+// and foreign keys are sorted by name and columns keep their ordinal order, so
+// two dumps of the same database are byte-for-byte identical.
+type Schema struct {
+	Tables []Table `json:"tables"`
+}
+
+type Table struct {
+	Name        string       `json:"name"`
+	Columns     []Column     `json:"columns"`
+	Indexes     []Index      `json:"indexes,omitempty"`
+	ForeignKeys []ForeignKey `json:"foreign_keys,omitempty"`
+}
+
+type Column struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Nullable bool    `json:"nullable"`
+	Default  *string `json:"default,omitempty"`
+	// Expression is true when Default is an expression such as
+	// CURRENT_TIMESTAMP rather than a literal value.
+	Expression bool   `json:"expression,omitempty"`
+	Extra      string `json:"extra,omitempty"`
+}
+
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+type ForeignKey struct {
+	Name              string   `json:"name"`
+	Columns           []string `json:"columns"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+	OnUpdate          string   `json:"on_update"`
+	OnDelete          string   `json:"on_delete"`
+}
+
+func (s *Schema) table(name string) *Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+	return nil
+}
+
+// Dump introspects the connected database. The migrations bookkeeping table
+// is left out.
+func (m *Migrator) Dump() (*Schema, error) {
+	schema := &Schema{}
+	tables := make(map[string]*Table)
+
+	rows, err := m.db.Query(`
+	SELECT table_name, column_name, column_type, is_nullable, column_default, extra
+	FROM information_schema.columns
+	WHERE table_schema = DATABASE() AND table_name <> 'migrations'
+	ORDER BY table_name, ordinal_position`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var tableName, nullable string
+		var column Column
+		var def sql.NullString
+		if err := rows.Scan(&tableName, &column.Name, &column.Type, &nullable, &def, &column.Extra); err != nil {
+			return err
+		}
+		column.Nullable = nullable == "YES"
+		column.Extra, column.Expression = normalizeExtra(column.Extra)
+		if def.Valid {
+			column.Default = &def.String
+			// MySQL 5.7 には DEFAULT_GENERATED がないので、CURRENT_TIMESTAMP は値から判定する
+			if !column.Expression && isCurrentTimestamp(column.Type, def.String) {
+				column.Expression = true
+			}
+		}
+
+		t, ok := tables[tableName]
+		if !ok {
+			schema.Tables = append(schema.Tables, Table{Name: tableName})
+			t = &schema.Tables[len(schema.Tables)-1]
+			tables[tableName] = t
+		}
+		t.Columns = append(t.Columns, column)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	// append 後にポインタが無効になるので取り直す
+	for i := range schema.Tables {
+		tables[schema.Tables[i].Name] = &schema.Tables[i]
+	}
+
+	rows, err = m.db.Query(`
+	SELECT table_name, index_name, non_unique, column_name
+	FROM information_schema.statistics
+	WHERE table_schema = DATABASE() AND table_name <> 'migrations'
+	ORDER BY table_name, index_name, seq_in_index`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	// 関数インデックス（MySQL 8.0.13+）の式の部分は column_name が NULL になる。
+	// 5.7 には expression 列がなく表現できないので、スナップショットには含めない
+	functional := make(map[[2]string]bool)
+	err = scanRows(rows, func() error {
+		var tableName, indexName string
+		var columnName sql.NullString
+		var nonUnique int
+		if err := rows.Scan(&tableName, &indexName, &nonUnique, &columnName); err != nil {
+			return err
+		}
+		t := tables[tableName]
+		if t == nil {
+			return nil
+		}
+		if !columnName.Valid {
+			functional[[2]string{tableName, indexName}] = true
+			return nil
+		}
+		if n := len(t.Indexes); n > 0 && t.Indexes[n-1].Name == indexName {
+			t.Indexes[n-1].Columns = append(t.Indexes[n-1].Columns, columnName.String)
+			return nil
+		}
+		t.Indexes = append(t.Indexes, Index{Name: indexName, Columns: []string{columnName.String}, Unique: nonUnique == 0})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexes: %w", err)
+	}
+	for key := range functional {
+		t := tables[key[0]]
+		t.Indexes = slices.DeleteFunc(t.Indexes, func(index Index) bool { return index.Name == key[1] })
+		log.Printf("Warning: skipping functional index %s on %s, expression indexes are not supported", key[1], key[0])
+	}
+
+	rows, err = m.db.Query(`
+	SELECT kcu.table_name, kcu.constraint_name, kcu.column_name, kcu.referenced_table_name,
+		kcu.referenced_column_name, rc.update_rule, rc.delete_rule
+	FROM information_schema.key_column_usage kcu
+	JOIN information_schema.referential_constraints rc
+		ON rc.constraint_schema = kcu.constraint_schema AND rc.constraint_name = kcu.constraint_name
+	WHERE kcu.table_schema = DATABASE() AND kcu.referenced_table_name IS NOT NULL
+	ORDER BY kcu.table_name, kcu.constraint_name, kcu.ordinal_position`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query foreign keys: %w", err)
+	}
+	err = scanRows(rows, func() error {
+		var tableName, name, column, refTable, refColumn, onUpdate, onDelete string
+		if err := rows.Scan(&tableName, &name, &column, &refTable, &refColumn, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		t := tables[tableName]
+		if t == nil {
+			return nil
+		}
+		if n := len(t.ForeignKeys); n > 0 && t.ForeignKeys[n-1].Name == name {
+			fk := &t.ForeignKeys[n-1]
+			fk.Columns = append(fk.Columns, column)
+			fk.ReferencedColumns = append(fk.ReferencedColumns, refColumn)
+			return nil
+		}
+		t.ForeignKeys = append(t.ForeignKeys, ForeignKey{
+			Name:              name,
+			Columns:           []string{column},
+			ReferencedTable:   refTable,
+			ReferencedColumns: []string{refColumn},
+			OnUpdate:          onUpdate,
+			OnDelete:          onDelete,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read foreign keys: %w", err)
+	}
+
+	schema.sort()
+	return schema, nil
+}
+
+func scanRows(rows *sql.Rows, scan func() error) error {
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// normalizeExtra strips MySQL's DEFAULT_GENERATED marker, which only flags
+// that the default is an expression and is not valid in a column definition.
+func normalizeExtra(extra string) (string, bool) {
+	const marker = "DEFAULT_GENERATED"
+	switch {
+	case extra == marker:
+		return "", true
+	case len(extra) > len(marker) && extra[:len(marker)+1] == marker+" ":
+		return extra[len(marker)+1:], true
+	}
+	return extra, false
+}
+
+// currentTimestamp matches CURRENT_TIMESTAMP with an optional fractional
+// seconds precision, the only expression default MySQL 5.7 allows.
+var currentTimestamp = regexp.MustCompile(`(?i)^current_timestamp(\(\d*\))?$`)
+
+// isCurrentTimestamp reports whether def is CURRENT_TIMESTAMP on a
+// TIMESTAMP or DATETIME column. Other column types can only have it as a
+// literal string.
+func isCurrentTimestamp(columnType, def string) bool {
+	columnType = strings.ToLower(columnType)
+	temporal := strings.HasPrefix(columnType, "timestamp") || strings.HasPrefix(columnType, "datetime")
+	return temporal && currentTimestamp.MatchString(def)
+}
+
+func (s *Schema) sort() {
+	sort.Slice(s.Tables, func(i, j int) bool { return s.Tables[i].Name < s.Tables[j].Name })
+	for i := range s.Tables {
+		t := &s.Tables[i]
+		sort.Slice(t.Indexes, func(a, b int) bool { return t.Indexes[a].Name < t.Indexes[b].Name })
+		sort.Slice(t.ForeignKeys, func(a, b int) bool { return t.ForeignKeys[a].Name < t.ForeignKeys[b].Name })
+	}
+}
+
+// LoadSchema reads a snapshot written by WriteSchema.
+func LoadSchema(path string) (*Schema, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema snapshot: %w", err)
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(content, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema snapshot %s: %w", path, err)
+	}
+	schema.sort()
+	return &schema, nil
+}
+
+// WriteSchema writes the snapshot in its canonical form.
+func WriteSchema(path string, schema *Schema) error {
+	var buf bytes.Buffer
+	if err := EncodeSchema(&buf, schema); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write schema snapshot: %w", err)
+	}
+	return nil
+}
+
+// EncodeSchema writes the snapshot to w in the form WriteSchema uses.
+func EncodeSchema(w io.Writer, schema *Schema) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(schema); err != nil {
+		return fmt.Errorf("failed to encode schema snapshot: %w", err)
+	}
+	return nil
+}
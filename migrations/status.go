@@ -0,0 +1,274 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Migration states reported by Status.
+const (
+	StateApplied  = "applied"
+	StatePending  = "pending"
+	StateModified = "modified" // applied, but the file changed afterwards
+	StateMissing  = "missing"  // applied, but no longer on disk
+)
+
+// MigrationStatus is the machine-readable status of a single migration.
+type MigrationStatus struct {
+	Version    int64      `json:"version" yaml:"version"`
+	Name       string     `json:"name" yaml:"name"`
+	Kind       string     `json:"kind" yaml:"kind"`
+	State      string     `json:"state" yaml:"state"`
+	AppliedAt  *time.Time `json:"applied_at,omitempty" yaml:"applied_at,omitempty"`
+	AppliedBy  string     `json:"applied_by,omitempty" yaml:"applied_by,omitempty"`
+	Checksum   string     `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	DurationMs int64      `json:"duration_ms" yaml:"duration_ms"`
+}
+
+type appliedRecord struct {
+	name       string
+	executedAt time.Time
+	checksum   string
+	durationMs int64
+	appliedBy  string
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Status returns the state of every migration on disk plus any applied
+// migration whose file has disappeared, ordered by version.
+func (m *Migrator) Status(migrationsDir string) ([]MigrationStatus, error) {
+	migrations, _, err := m.prepare(migrationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := m.getAppliedRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := MigrationStatus{
+			Version:  migration.Version,
+			Name:     migration.Name,
+			Kind:     "sql",
+			State:    StatePending,
+			Checksum: migration.Checksum,
+		}
+		if migration.IsGo() {
+			status.Kind = "go"
+		}
+
+		if record, ok := records[migration.Version]; ok {
+			status.State = StateApplied
+			if record.checksum != "" && record.checksum != migration.Checksum {
+				status.State = StateModified
+			}
+			status.AppliedAt = &record.executedAt
+			status.AppliedBy = record.appliedBy
+			status.DurationMs = record.durationMs
+			delete(records, migration.Version)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	for version, record := range records {
+		statuses = append(statuses, MigrationStatus{
+			Version:    version,
+			Name:       record.name,
+			Kind:       "unknown",
+			State:      StateMissing,
+			AppliedAt:  &record.executedAt,
+			AppliedBy:  record.appliedBy,
+			Checksum:   record.checksum,
+			DurationMs: record.durationMs,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Pending returns the migrations of migrations, as returned by
+// LoadMigrations, that have not been applied yet. It never writes and only
+// queries the database, so it is cheap enough for health checks; a missing
+// migrations table means everything is pending.
+func (m *Migrator) Pending(ctx context.Context, migrations []Migration) ([]Migration, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'migrations'"
+	if err := m.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return nil, fmt.Errorf("failed to check migrations table: %w", err)
+	}
+	if count == 0 {
+		return migrations, nil
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version FROM migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan migration version: %w", err)
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	var pending []Migration
+	for _, migration := range migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) getAppliedRecords() (map[int64]appliedRecord, error) {
+	records := make(map[int64]appliedRecord)
+
+	if m.dryRun {
+		exists, err := m.migrationsTableExists()
+		if err != nil || !exists {
+			return records, err
+		}
+		// 旧形式のテーブルは dry-run ではアップグレードしないので、列の有無を確認する
+		missing, err := m.missingMigrationColumns()
+		if err != nil {
+			return records, err
+		}
+		if len(missing) > 0 {
+			return m.getLegacyAppliedRecords()
+		}
+	}
+
+	rows, err := m.db.Query("SELECT version, name, executed_at, checksum, duration_ms, applied_by FROM migrations ORDER BY version")
+	if err != nil {
+		return records, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var record appliedRecord
+		if err := rows.Scan(&version, &record.name, &record.executedAt, &record.checksum, &record.durationMs, &record.appliedBy); err != nil {
+			return records, fmt.Errorf("failed to scan migration record: %w", err)
+		}
+		records[version] = record
+	}
+
+	return records, rows.Err()
+}
+
+func (m *Migrator) getLegacyAppliedRecords() (map[int64]appliedRecord, error) {
+	records := make(map[int64]appliedRecord)
+
+	rows, err := m.db.Query("SELECT version, name, executed_at FROM migrations ORDER BY version")
+	if err != nil {
+		return records, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var record appliedRecord
+		if err := rows.Scan(&version, &record.name, &record.executedAt); err != nil {
+			return records, fmt.Errorf("failed to scan migration record: %w", err)
+		}
+		records[version] = record
+	}
+
+	return records, rows.Err()
+}
+
+// migrationColumns are the bookkeeping columns added after the migrations
+// table was first introduced, with their definitions.
+var migrationColumns = []struct {
+	name       string
+	definition string
+}{
+	{"checksum", "VARCHAR(64) NOT NULL DEFAULT ''"},
+	{"duration_ms", "BIGINT NOT NULL DEFAULT 0"},
+	{"applied_by", "VARCHAR(255) NOT NULL DEFAULT ''"},
+}
+
+func (m *Migrator) missingMigrationColumns() ([]int, error) {
+	rows, err := m.db.Query("SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'migrations'")
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect migrations table: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, fmt.Errorf("failed to scan column name: %w", err)
+		}
+		existing[column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []int
+	for i, column := range migrationColumns {
+		if !existing[column.name] {
+			missing = append(missing, i)
+		}
+	}
+	return missing, nil
+}
+
+// upgradeMigrationsTable adds the bookkeeping columns to a migrations table
+// created by an older version of the migrator.
+func (m *Migrator) upgradeMigrationsTable() error {
+	missing, err := m.missingMigrationColumns()
+	if err != nil {
+		return err
+	}
+
+	for _, i := range missing {
+		column := migrationColumns[i]
+		query := fmt.Sprintf("ALTER TABLE migrations ADD COLUMN %s %s", column.name, column.definition)
+		if _, err := m.db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add %s to migrations table: %w", column.name, err)
+		}
+	}
+
+	return nil
+}
+
+func recordMigration(ctx context.Context, db execer, migration Migration, duration time.Duration) error {
+	_, err := db.ExecContext(ctx,
+		"INSERT INTO migrations (version, name, checksum, duration_ms, applied_by) VALUES (?, ?, ?, ?, ?)",
+		migration.Version, migration.Name, migration.Checksum, duration.Milliseconds(), appliedBy())
+	return err
+}
+
+// appliedBy identifies the host applying migrations.
+func appliedBy() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return host
+}
@@ -0,0 +1,29 @@
+package models
+
+import "strings"
+
+// maxKeysPerQuery caps the number of keys bound in one IN (...) clause.
+const maxKeysPerQuery = 1000
+
+// inChunks runs fetch for consecutive chunks of at most maxKeysPerQuery keys
+// and concatenates the results.
+func inChunks[K, V any](keys []K, fetch func([]K) ([]V, error)) ([]V, error) {
+	rows := []V{}
+	for start := 0; start < len(keys); start += maxKeysPerQuery {
+		chunk, err := fetch(keys[start:min(start+maxKeysPerQuery, len(keys))])
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, chunk...)
+	}
+	return rows, nil
+}
+
+// inArgs returns the "?,?,?" placeholder list and bind arguments for keys.
+func inArgs[K any](keys []K) (string, []any) {
+	args := make([]any, len(keys))
+	for i, k := range keys {
+		args[i] = k
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(keys)), ","), args
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"context"
+	"strconv"
+
+	"graphql-backend/cache"
+	"graphql-backend/database"
+)
+
+// Keys of the shared cache entries written by the loaders; repositories
+// delete them when the underlying rows change.
+
+func UserCacheKey(id int) string {
+	return "user:" + strconv.Itoa(id)
+}
+
+func PostsByUserCacheKey(userID int) string {
+	return "posts_by_user:" + strconv.Itoa(userID)
+}
+
+// RecentlyWrittenKey marks that the rows behind key were written less than
+// the replica staleness ago, so a cache miss on key must be read from the
+// primary. Otherwise a lagging replica could refill the entry with the old
+// rows for a whole TTL.
+func RecentlyWrittenKey(key string) string {
+	return "written:" + key
+}
+
+// invalidate deletes keys from store after a write and marks them as
+// recently written; store may be nil.
+func invalidate(ctx context.Context, db *database.DB, store cache.Store, keys ...string) {
+	if store == nil {
+		return
+	}
+	store.Delete(ctx, keys...)
+	if staleness := db.ReplicaStaleness(); staleness > 0 {
+		for _, key := range keys {
+			store.Set(ctx, RecentlyWrittenKey(key), []byte{1}, staleness)
+		}
+	}
+}
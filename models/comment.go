@@ -0,0 +1,188 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"graphql-backend/database"
+)
+
+type Comment struct {
+	ID        int       `json:"id" db:"id"`
+	PostID    int       `json:"post_id" db:"post_id"`
+	ParentID  *int      `json:"parent_id" db:"parent_id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Body      string    `json:"body" db:"body"`
+	Depth     int       `json:"depth" db:"depth"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DepthError is returned when a reply would be nested deeper than allowed.
+type DepthError struct {
+	MaxDepth int
+}
+
+func (e *DepthError) Error() string {
+	return fmt.Sprintf("replies can be nested at most %d levels deep", e.MaxDepth)
+}
+
+// Extensions sets the code of the GraphQL error the resolver returns.
+func (e *DepthError) Extensions() map[string]any {
+	return map[string]any{"code": "MAX_DEPTH_EXCEEDED", "maxDepth": e.MaxDepth}
+}
+
+const commentColumns = "id, post_id, parent_id, user_id, body, depth, created_at, updated_at"
+
+type CommentRepository struct {
+	db *database.DB
+}
+
+func NewCommentRepository(db *database.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+func (r *CommentRepository) GetByID(ctx context.Context, id int) (*Comment, error) {
+	query := "SELECT " + commentColumns + " FROM comments WHERE id = ?"
+	row := r.db.Reader(ctx).QueryRowContext(ctx, query, id)
+
+	comment, err := scanComment(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("comment not found")
+		}
+		return nil, fmt.Errorf("failed to scan comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+// GetPageByPostIDs returns, for each of postIDs, at most limit top-level
+// comments with an ID greater than after, oldest first.
+func (r *CommentRepository) GetPageByPostIDs(ctx context.Context, postIDs []int, limit, after int) ([]*Comment, error) {
+	return inChunks(postIDs, func(ids []int) ([]*Comment, error) {
+		return r.getPage(ctx, "post_id", "parent_id IS NULL", ids, limit, after)
+	})
+}
+
+// GetRepliesPage returns, for each of parentIDs, at most limit direct
+// replies with an ID greater than after, oldest first.
+func (r *CommentRepository) GetRepliesPage(ctx context.Context, parentIDs []int, limit, after int) ([]*Comment, error) {
+	return inChunks(parentIDs, func(ids []int) ([]*Comment, error) {
+		return r.getPage(ctx, "parent_id", "TRUE", ids, limit, after)
+	})
+}
+
+// getPage numbers the matching rows per parent so that one query returns
+// the first page of every parent in ids.
+func (r *CommentRepository) getPage(ctx context.Context, parent, filter string, ids []int, limit, after int) ([]*Comment, error) {
+	placeholders, args := inArgs(ids)
+	query := fmt.Sprintf(`SELECT %[1]s FROM (
+		SELECT %[1]s, ROW_NUMBER() OVER (PARTITION BY %[2]s ORDER BY id) AS rn
+		FROM comments WHERE %[2]s IN (%[3]s) AND %[4]s AND id > ?
+	) page WHERE rn <= ? ORDER BY id`, commentColumns, parent, placeholders, filter)
+	args = append(args, after, limit)
+
+	rows, err := r.db.Reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, nil
+}
+
+// Create adds a comment to postID, or a reply to parentID when it is set.
+// Replies must belong to the same post and may be nested at most maxDepth
+// levels below a top-level comment.
+func (r *CommentRepository) Create(ctx context.Context, postID int, parentID *int, userID int, body string, maxDepth int) (*Comment, error) {
+	ctx = database.MarkWrite(ctx)
+
+	depth := 0
+	if parentID != nil {
+		parent, err := r.GetByID(ctx, *parentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.PostID != postID {
+			return nil, fmt.Errorf("parent comment %d belongs to another post", parent.ID)
+		}
+		depth = parent.Depth + 1
+		if depth > maxDepth {
+			return nil, &DepthError{MaxDepth: maxDepth}
+		}
+	}
+
+	query := "INSERT INTO comments (post_id, parent_id, user_id, body, depth) VALUES (?, ?, ?, ?, ?)"
+	result, err := r.db.Writer().ExecContext(ctx, query, postID, parentID, userID, body, depth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return r.GetByID(ctx, int(id))
+}
+
+func (r *CommentRepository) Update(ctx context.Context, id int, body string) (*Comment, error) {
+	ctx = database.MarkWrite(ctx)
+
+	query := "UPDATE comments SET body = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?"
+	_, err := r.db.Writer().ExecContext(ctx, query, body, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	// 本文が同じだと RowsAffected は 0 になるので、存在確認は GetByID に任せる
+	return r.GetByID(ctx, id)
+}
+
+// Delete removes a comment together with its replies (ON DELETE CASCADE).
+func (r *CommentRepository) Delete(ctx context.Context, id int) error {
+	ctx = database.MarkWrite(ctx)
+
+	query := "DELETE FROM comments WHERE id = ?"
+	result, err := r.db.Writer().ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("comment not found")
+	}
+
+	return nil
+}
+
+func scanComment(row interface{ Scan(...any) error }) (*Comment, error) {
+	comment := &Comment{}
+	var parentID sql.NullInt64
+	err := row.Scan(&comment.ID, &comment.PostID, &parentID, &comment.UserID, &comment.Body, &comment.Depth, &comment.CreatedAt, &comment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		comment.ParentID = &id
+	}
+	return comment, nil
+}
@@ -0,0 +1,168 @@
+package loaders
+
+import (
+	"context"
+	"fmt"
+
+	"graphql-backend/config"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+// NotFoundError is returned for a key a one-to-one loader found no row for.
+// Other keys of the same batch are unaffected.
+type NotFoundError struct {
+	Loader string
+	Key    any
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s: %v not found", e.Loader, e.Key)
+}
+
+// Extensions sets the code of the GraphQL error the resolver returns.
+func (e *NotFoundError) Extensions() map[string]any {
+	return map[string]any{"code": "NOT_FOUND"}
+}
+
+// fetchFunc loads the rows for a batch of keys, typically a repository
+// method running one WHERE ... IN (...) query.
+type fetchFunc[K comparable, V any] func(ctx context.Context, keys []K) ([]V, error)
+
+// newOneToMany builds a loader returning, for each key, the rows whose
+// foreign key (as returned by keyOf) equals it. Keys without rows get an
+// empty slice.
+func newOneToMany[K comparable, V any](name string, cfg config.DataLoaderConfig, fetch fetchFunc[K, V], keyOf func(V) K) *dataloader.Loader[K, []V] {
+	batch := func(ctx context.Context, keys []K) []*dataloader.Result[[]V] {
+		rows, err := fetch(ctx, keys)
+		if err != nil {
+			return failAll[K, []V](keys, err)
+		}
+
+		group := make(map[K][]V, len(keys))
+		for _, row := range rows {
+			k := keyOf(row)
+			group[k] = append(group[k], row)
+		}
+
+		res := make([]*dataloader.Result[[]V], len(keys))
+		for i, k := range keys {
+			res[i] = &dataloader.Result[[]V]{Data: group[k]}
+		}
+		return res
+	}
+
+	return dataloader.NewBatchedLoader(batch, loaderOptions[K, []V](name, cfg)...)
+}
+
+// newManyToMany builds a loader for a relation stored in a join table.
+// fetch returns one row per link, typically the join table joined with the
+// target table, and link splits a row into the key it belongs to and the
+// value to return. Keys without links get an empty slice.
+func newManyToMany[K comparable, L, V any](name string, cfg config.DataLoaderConfig, fetch fetchFunc[K, L], link func(L) (K, V)) *dataloader.Loader[K, []V] {
+	batch := func(ctx context.Context, keys []K) []*dataloader.Result[[]V] {
+		rows, err := fetch(ctx, keys)
+		if err != nil {
+			return failAll[K, []V](keys, err)
+		}
+
+		group := make(map[K][]V, len(keys))
+		for _, row := range rows {
+			k, v := link(row)
+			group[k] = append(group[k], v)
+		}
+
+		res := make([]*dataloader.Result[[]V], len(keys))
+		for i, k := range keys {
+			res[i] = &dataloader.Result[[]V]{Data: group[k]}
+		}
+		return res
+	}
+
+	return dataloader.NewBatchedLoader(batch, loaderOptions[K, []V](name, cfg)...)
+}
+
+// newOneToOne builds a loader returning the row whose primary key (as
+// returned by keyOf) equals each key, or a NotFoundError for that key.
+func newOneToOne[K comparable, V any](name string, cfg config.DataLoaderConfig, fetch fetchFunc[K, V], keyOf func(V) K) *dataloader.Loader[K, V] {
+	batch := func(ctx context.Context, keys []K) []*dataloader.Result[V] {
+		rows, err := fetch(ctx, keys)
+		if err != nil {
+			return failAll[K, V](keys, err)
+		}
+
+		byKey := make(map[K]V, len(rows))
+		for _, row := range rows {
+			byKey[keyOf(row)] = row
+		}
+
+		res := make([]*dataloader.Result[V], len(keys))
+		for i, k := range keys {
+			if row, ok := byKey[k]; ok {
+				res[i] = &dataloader.Result[V]{Data: row}
+			} else {
+				res[i] = &dataloader.Result[V]{Error: &NotFoundError{Loader: name, Key: k}}
+			}
+		}
+		return res
+	}
+
+	return dataloader.NewBatchedLoader(batch, loaderOptions[K, V](name, cfg)...)
+}
+
+// PageKey selects a page of the rows belonging to the parent ID: at most
+// Limit rows whose ID is greater than After (0 for the first page).
+type PageKey struct {
+	ID    int
+	Limit int
+	After int
+}
+
+// pageFetchFunc loads one page of rows for each of a batch of parent IDs,
+// typically a repository method numbering the rows per parent.
+type pageFetchFunc[V any] func(ctx context.Context, ids []int, limit, after int) ([]V, error)
+
+// newPaged builds a loader returning the requested page for each key, with
+// idOf returning the parent ID of a row. Keys asking for the same window
+// (typically every parent in a list requesting its first page) share one
+// fetch.
+func newPaged[V any](name string, cfg config.DataLoaderConfig, fetch pageFetchFunc[V], idOf func(V) int) *dataloader.Loader[PageKey, []V] {
+	type window struct{ limit, after int }
+
+	batch := func(ctx context.Context, keys []PageKey) []*dataloader.Result[[]V] {
+		ids := make(map[window][]int)
+		for _, k := range keys {
+			w := window{k.Limit, k.After}
+			ids[w] = append(ids[w], k.ID)
+		}
+
+		pages := make(map[PageKey][]V, len(keys))
+		for w, ids := range ids {
+			rows, err := fetch(ctx, ids, w.limit, w.after)
+			if err != nil {
+				return failAll[PageKey, []V](keys, err)
+			}
+			for _, row := range rows {
+				k := PageKey{ID: idOf(row), Limit: w.limit, After: w.after}
+				pages[k] = append(pages[k], row)
+			}
+		}
+
+		res := make([]*dataloader.Result[[]V], len(keys))
+		for i, k := range keys {
+			res[i] = &dataloader.Result[[]V]{Data: pages[k]}
+		}
+		return res
+	}
+
+	return dataloader.NewBatchedLoader(batch, loaderOptions[PageKey, []V](name, cfg)...)
+}
+
+// failAll reports err for every key of a batch whose fetch failed.
+func failAll[K comparable, V any](keys []K, err error) []*dataloader.Result[V] {
+	res := make([]*dataloader.Result[V], len(keys))
+	for i := range res {
+		res[i] = &dataloader.Result[V]{Error: err}
+	}
+	return res
+}
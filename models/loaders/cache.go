@@ -0,0 +1,61 @@
+package loaders
+
+import (
+	"context"
+
+	"graphql-backend/config"
+	"graphql-backend/telemetry"
+
+	"github.com/graph-gophers/dataloader/v7"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// newCache returns the per-request cache selected by cfg.Cache.
+func newCache[K comparable, V any](cfg config.DataLoaderConfig) dataloader.Cache[K, V] {
+	switch cfg.Cache {
+	case "none":
+		return &dataloader.NoCache[K, V]{}
+	case "lru":
+		cache, _ := lru.New[K, dataloader.Thunk[V]](cfg.CacheSize)
+		return &lruCache[K, V]{cache: cache}
+	default:
+		return dataloader.NewCache[K, V]()
+	}
+}
+
+// lruCache bounds the memory a single request's loader can hold on to.
+type lruCache[K comparable, V any] struct {
+	cache *lru.Cache[K, dataloader.Thunk[V]]
+}
+
+func (c *lruCache[K, V]) Get(_ context.Context, key K) (dataloader.Thunk[V], bool) {
+	return c.cache.Get(key)
+}
+
+func (c *lruCache[K, V]) Set(_ context.Context, key K, value dataloader.Thunk[V]) {
+	c.cache.Add(key, value)
+}
+
+func (c *lruCache[K, V]) Delete(_ context.Context, key K) bool {
+	return c.cache.Remove(key)
+}
+
+func (c *lruCache[K, V]) Clear() {
+	c.cache.Purge()
+}
+
+// countingCache wraps a loader's per-request cache to count hits and misses.
+type countingCache[K comparable, V any] struct {
+	dataloader.Cache[K, V]
+	name string
+}
+
+func (c countingCache[K, V]) Get(ctx context.Context, key K) (dataloader.Thunk[V], bool) {
+	thunk, ok := c.Cache.Get(ctx, key)
+	if ok {
+		telemetry.LoaderCacheLookups.WithLabelValues(c.name, "hit").Inc()
+	} else {
+		telemetry.LoaderCacheLookups.WithLabelValues(c.name, "miss").Inc()
+	}
+	return thunk, ok
+}
@@ -0,0 +1,16 @@
+package loaders
+
+import (
+	"graphql-backend/config"
+	"graphql-backend/models"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+type commentPageLoader = dataloader.Loader[PageKey, []*models.Comment]
+
+func newCommentLoaders(commentRepo *models.CommentRepository, cfg config.DataLoaderConfig) (byPost, replies *commentPageLoader) {
+	byPost = newPaged("comments_by_post_id", cfg, commentRepo.GetPageByPostIDs, func(c *models.Comment) int { return c.PostID })
+	replies = newPaged("comment_replies", cfg, commentRepo.GetRepliesPage, func(c *models.Comment) int { return *c.ParentID })
+	return byPost, replies
+}
@@ -1,6 +1,8 @@
 package loaders
 
 import (
+	"graphql-backend/cache"
+	"graphql-backend/config"
 	"graphql-backend/models"
 
 	"github.com/graph-gophers/dataloader/v7"
@@ -9,13 +11,46 @@ import (
 type Loaders struct {
 	// key: int64(user_id), value: []*model.Post
 	PostsByUserID *dataloader.Loader[int, []*models.Post]
+	// key: user_id, value: *model.User（存在しない ID は NotFoundError）
+	UserByID *dataloader.Loader[int, *models.User]
+	// key: post_id + page, value: top-level comments of the page
+	CommentsByPostID *dataloader.Loader[PageKey, []*models.Comment]
+	// key: comment_id + page, value: direct replies of the page
+	CommentReplies *dataloader.Loader[PageKey, []*models.Comment]
+	// key: post_id, value: []*model.Tag（post_tags 経由）
+	TagsByPostID *dataloader.Loader[int, []*models.Tag]
 }
 
 
-func NewLoaders(postRepo *models.PostRepository) *Loaders {
-	postLoader :=  newPostLoaders(postRepo)
+// NewLoaders builds one request's loaders. store is the shared cache behind
+// them and may be nil.
+func NewLoaders(userRepo *models.UserRepository, postRepo *models.PostRepository, commentRepo *models.CommentRepository, tagRepo *models.TagRepository, store cache.Store, cfg config.DataLoaderConfig) *Loaders {
+	postLoader := newPostLoaders(postRepo, store, cfg)
+	userLoader := newUserLoaders(userRepo, store, cfg)
+	commentLoader, replyLoader := newCommentLoaders(commentRepo, cfg)
+	tagLoader := newTagLoaders(tagRepo, cfg)
 
 	return &Loaders{
-		PostsByUserID: postLoader,
+		PostsByUserID:    postLoader,
+		UserByID:         userLoader,
+		CommentsByPostID: commentLoader,
+		CommentReplies:   replyLoader,
+		TagsByPostID:     tagLoader,
 	}
-}
\ No newline at end of file
+}
+
+// loaderOptions builds the options of the named loader from its effective
+// settings (see config.DataLoaderConfig.Loader).
+func loaderOptions[K comparable, V any](name string, cfg config.DataLoaderConfig) []dataloader.Option[K, V] {
+	cfg = cfg.Loader(name)
+
+	opts := []dataloader.Option[K, V]{
+		dataloader.WithWait[K, V](cfg.Wait.Duration),
+		dataloader.WithTracer[K, V](batchTracer[K, V]{name: name}),
+		dataloader.WithCache[K, V](countingCache[K, V]{Cache: newCache[K, V](cfg), name: name}),
+	}
+	if cfg.MaxBatch > 0 {
+		opts = append(opts, dataloader.WithBatchCapacity[K, V](cfg.MaxBatch))
+	}
+	return opts
+}
@@ -2,16 +2,18 @@ package loaders
 
 import (
 	"context"
+	"graphql-backend/cache"
+	"graphql-backend/config"
 	"graphql-backend/models"
 	"net/http"
 )
 
 type ctxKey struct{}
 
-func Middleware(repo *models.PostRepository) func(http.Handler) http.Handler {
+func Middleware(userRepo *models.UserRepository, postRepo *models.PostRepository, commentRepo *models.CommentRepository, tagRepo *models.TagRepository, store cache.Store, cfg config.DataLoaderConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			lds := NewLoaders(repo) // ★ リクエストごとに新しいLoaders
+			lds := NewLoaders(userRepo, postRepo, commentRepo, tagRepo, store, cfg) // ★ リクエストごとに新しいLoaders
 			ctx := context.WithValue(r.Context(), ctxKey{}, lds)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
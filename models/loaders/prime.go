@@ -0,0 +1,58 @@
+package loaders
+
+import (
+	"context"
+
+	"graphql-backend/models"
+)
+
+// The hooks below keep the per-request caches consistent with writes made
+// earlier in the same request, so a mutation's response (or a later
+// operation in a batch) does not return rows loaded before the write. They
+// are no-ops when the request has no Loaders.
+
+// PrimeUser caches the freshly written u, replacing any stale entry.
+func (l *Loaders) PrimeUser(ctx context.Context, u *models.User) {
+	if l == nil {
+		return
+	}
+	l.UserByID.Clear(ctx, u.ID).Prime(ctx, u.ID, u)
+}
+
+// ClearUser drops a deleted user and everything loaded through it.
+func (l *Loaders) ClearUser(ctx context.Context, userID int) {
+	if l == nil {
+		return
+	}
+	l.UserByID.Clear(ctx, userID)
+	l.PostsByUserID.Clear(ctx, userID)
+}
+
+// ClearPostsByUser invalidates the posts of userID after a post of theirs
+// was created, changed or deleted.
+func (l *Loaders) ClearPostsByUser(ctx context.Context, userID int) {
+	if l == nil {
+		return
+	}
+	l.PostsByUserID.Clear(ctx, userID)
+}
+
+// ClearComments invalidates every loaded page of comments and replies after
+// a comment was added, edited or deleted. Pages are keyed by their cursor,
+// so the affected entries cannot be singled out.
+func (l *Loaders) ClearComments(ctx context.Context) {
+	if l == nil {
+		return
+	}
+	l.CommentsByPostID.ClearAll()
+	l.CommentReplies.ClearAll()
+}
+
+// ClearTagsByPost invalidates the tags of postID after tags were attached
+// to or detached from it.
+func (l *Loaders) ClearTagsByPost(ctx context.Context, postID int) {
+	if l == nil {
+		return
+	}
+	l.TagsByPostID.Clear(ctx, postID)
+}
@@ -0,0 +1,135 @@
+package loaders
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"graphql-backend/cache"
+	"graphql-backend/database"
+	"graphql-backend/models"
+	"graphql-backend/telemetry"
+)
+
+// sharedOne puts the shared cache in front of a one-to-one fetch: keys found
+// in store are served from it and only the rest are fetched and stored.
+// Missing rows are not cached. With a nil store fetch is returned unchanged.
+func sharedOne[K comparable, V any](name string, store cache.Store, ttl time.Duration, cacheKey func(K) string, fetch fetchFunc[K, V], keyOf func(V) K) fetchFunc[K, V] {
+	if store == nil {
+		return fetch
+	}
+
+	return func(ctx context.Context, keys []K) ([]V, error) {
+		var rows []V
+		misses := lookup(ctx, name, store, keys, cacheKey, func(row V) {
+			rows = append(rows, row)
+		})
+		if len(misses) == 0 {
+			return rows, nil
+		}
+
+		fetched, err := fetchMisses(ctx, store, misses, cacheKey, fetch)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range fetched {
+			save(ctx, store, cacheKey(keyOf(row)), row, ttl)
+		}
+		return append(rows, fetched...), nil
+	}
+}
+
+// sharedMany is sharedOne for one-to-many fetches. Every key's whole group
+// is one entry, so keys without rows are cached as empty groups.
+func sharedMany[K comparable, V any](name string, store cache.Store, ttl time.Duration, cacheKey func(K) string, fetch fetchFunc[K, V], keyOf func(V) K) fetchFunc[K, V] {
+	if store == nil {
+		return fetch
+	}
+
+	return func(ctx context.Context, keys []K) ([]V, error) {
+		var rows []V
+		misses := lookup(ctx, name, store, keys, cacheKey, func(group []V) {
+			rows = append(rows, group...)
+		})
+		if len(misses) == 0 {
+			return rows, nil
+		}
+
+		fetched, err := fetchMisses(ctx, store, misses, cacheKey, fetch)
+		if err != nil {
+			return nil, err
+		}
+		groups := make(map[K][]V, len(misses))
+		for _, row := range fetched {
+			groups[keyOf(row)] = append(groups[keyOf(row)], row)
+		}
+		for _, k := range misses {
+			group := groups[k]
+			if group == nil {
+				group = []V{}
+			}
+			save(ctx, store, cacheKey(k), group, ttl)
+		}
+		return append(rows, fetched...), nil
+	}
+}
+
+// fetchMisses fetches the keys that were not cached. Keys whose rows were
+// written recently (see models.RecentlyWrittenKey) are read from the
+// primary, since a lagging replica could still return the old rows and they
+// would be cached for the whole TTL; the others are read as usual.
+func fetchMisses[K comparable, V any](ctx context.Context, store cache.Store, misses []K, cacheKey func(K) string, fetch fetchFunc[K, V]) ([]V, error) {
+	var recent, rest []K
+	for _, k := range misses {
+		if _, ok := store.Get(ctx, models.RecentlyWrittenKey(cacheKey(k))); ok {
+			recent = append(recent, k)
+		} else {
+			rest = append(rest, k)
+		}
+	}
+
+	var rows []V
+	if len(rest) > 0 {
+		fetched, err := fetch(ctx, rest)
+		if err != nil {
+			return nil, err
+		}
+		rows = fetched
+	}
+	if len(recent) > 0 {
+		fetched, err := fetch(database.UsePrimary(ctx), recent)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, fetched...)
+	}
+	return rows, nil
+}
+
+// lookup decodes the cached entries of keys into hit and returns the keys
+// that were not cached.
+func lookup[K comparable, T any](ctx context.Context, name string, store cache.Store, keys []K, cacheKey func(K) string, hit func(T)) []K {
+	var misses []K
+	for _, k := range keys {
+		raw, ok := store.Get(ctx, cacheKey(k))
+		var value T
+		if ok && json.Unmarshal(raw, &value) == nil {
+			telemetry.SharedCacheLookups.WithLabelValues(name, "hit").Inc()
+			hit(value)
+			continue
+		}
+		telemetry.SharedCacheLookups.WithLabelValues(name, "miss").Inc()
+		misses = append(misses, k)
+	}
+	return misses
+}
+
+func save(ctx context.Context, store cache.Store, key string, value any, ttl time.Duration) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to encode cache entry %s: %v", key, err)
+		return
+	}
+	store.Set(ctx, key, raw, ttl)
+}
@@ -0,0 +1,15 @@
+package loaders
+
+import (
+	"graphql-backend/config"
+	"graphql-backend/models"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+type tagLoader = dataloader.Loader[int, []*models.Tag]
+
+func newTagLoaders(tagRepo *models.TagRepository, cfg config.DataLoaderConfig) *tagLoader {
+	link := func(pt *models.PostTag) (int, *models.Tag) { return pt.PostID, &pt.Tag }
+	return newManyToMany("tags_by_post_id", cfg, tagRepo.GetTagsByPostIDs, link)
+}
@@ -0,0 +1,48 @@
+package loaders
+
+import (
+	"context"
+
+	"graphql-backend/telemetry"
+
+	"github.com/graph-gophers/dataloader/v7"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("graphql-backend/models/loaders")
+
+// batchTracer records a span and the batch size metric per batch.
+// Individual loads are not traced; they would only add one span per resolver.
+type batchTracer[K comparable, V any] struct {
+	name string
+}
+
+func (t batchTracer[K, V]) TraceLoad(ctx context.Context, key K) (context.Context, dataloader.TraceLoadFinishFunc[V]) {
+	return ctx, func(dataloader.Thunk[V]) {}
+}
+
+func (t batchTracer[K, V]) TraceLoadMany(ctx context.Context, keys []K) (context.Context, dataloader.TraceLoadManyFinishFunc[V]) {
+	return ctx, func(dataloader.ThunkMany[V]) {}
+}
+
+func (t batchTracer[K, V]) TraceBatch(ctx context.Context, keys []K) (context.Context, dataloader.TraceBatchFinishFunc[V]) {
+	telemetry.LoaderBatchSize.WithLabelValues(t.name).Observe(float64(len(keys)))
+
+	ctx, span := tracer.Start(ctx, "dataloader.batch "+t.name, trace.WithAttributes(
+		attribute.String("dataloader.name", t.name),
+		attribute.Int("dataloader.batch_size", len(keys)),
+	))
+
+	return ctx, func(results []*dataloader.Result[V]) {
+		for _, r := range results {
+			if r != nil && r.Error != nil {
+				span.SetStatus(codes.Error, r.Error.Error())
+				break
+			}
+		}
+		span.End()
+	}
+}
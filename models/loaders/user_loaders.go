@@ -0,0 +1,17 @@
+package loaders
+
+import (
+	"graphql-backend/cache"
+	"graphql-backend/config"
+	"graphql-backend/models"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+type userLoader = dataloader.Loader[int, *models.User]
+
+func newUserLoaders(userRepo *models.UserRepository, store cache.Store, cfg config.DataLoaderConfig) *userLoader {
+	keyOf := func(u *models.User) int { return u.ID }
+	fetch := sharedOne("user_by_id", store, cfg.Shared.TTL.Duration, models.UserCacheKey, userRepo.GetByIDs, keyOf)
+	return newOneToOne("user_by_id", cfg, fetch, keyOf)
+}
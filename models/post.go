@@ -1,10 +1,13 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"strings"
 	"time"
+
+	"graphql-backend/cache"
+	"graphql-backend/database"
 )
 
 type Post struct {
@@ -17,16 +20,82 @@ type Post struct {
 }
 
 type PostRepository struct {
-	db *sql.DB
+	db    *database.DB
+	cache cache.Store
+}
+
+// NewPostRepository returns a repository that invalidates the entries of store
+// (which may be nil) when it writes.
+func NewPostRepository(db *database.DB, store cache.Store) *PostRepository {
+	return &PostRepository{db: db, cache: store}
+}
+
+func (r *PostRepository) GetAll(ctx context.Context) ([]*Post, error) {
+	query := "SELECT id, user_id, title, content, created_at, updated_at FROM posts ORDER BY id"
+	rows, err := r.db.Reader(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []*Post
+	for rows.Next() {
+		post := &Post{}
+		err := rows.Scan(&post.ID, &post.UserID, &post.Title, &post.Content, &post.CreatedAt, &post.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
+
+// GetByTag returns the posts carrying the tag with the normalized slug.
+func (r *PostRepository) GetByTag(ctx context.Context, slug string) ([]*Post, error) {
+	query := `SELECT p.id, p.user_id, p.title, p.content, p.created_at, p.updated_at
+		FROM posts p
+		JOIN post_tags pt ON pt.post_id = p.id
+		JOIN tags t ON t.id = pt.tag_id
+		WHERE t.slug = ? ORDER BY p.id`
+	rows, err := r.db.Reader(ctx).QueryContext(ctx, query, slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []*Post
+	for rows.Next() {
+		post := &Post{}
+		err := rows.Scan(&post.ID, &post.UserID, &post.Title, &post.Content, &post.CreatedAt, &post.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, nil
 }
 
-func NewPostRepository(db *sql.DB) *PostRepository {
-	return &PostRepository{db: db}
+func (r *PostRepository) GetByID(ctx context.Context, id int) (*Post, error) {
+	query := "SELECT id, user_id, title, content, created_at, updated_at FROM posts WHERE id = ?"
+	row := r.db.Reader(ctx).QueryRowContext(ctx, query, id)
+
+	post := &Post{}
+	err := row.Scan(&post.ID, &post.UserID, &post.Title, &post.Content, &post.CreatedAt, &post.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("post not found")
+		}
+		return nil, fmt.Errorf("failed to scan post: %w", err)
+	}
+
+	return post, nil
 }
 
-func (r *PostRepository) GetPostsByUserID(userID int) ([]*Post, error) {
+func (r *PostRepository) GetPostsByUserID(ctx context.Context, userID int) ([]*Post, error) {
 	query := "SELECT id, user_id, title, content, created_at, updated_at FROM posts WHERE user_id = ? ORDER BY id"
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.Reader(ctx).QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query users: %w", err)
 	}
@@ -45,20 +114,20 @@ func (r *PostRepository) GetPostsByUserID(userID int) ([]*Post, error) {
 	return users, nil
 }
 
-func (r *PostRepository) GetPostsByUserIDs(userIDs []int) ([]*Post, error) {
-	if len(userIDs) == 0 {
-		return []*Post{}, nil
-	}
+// GetPostsByUserIDs returns the posts of all userIDs. Large key sets are
+// split into queries of at most maxKeysPerQuery keys so the IN clause stays
+// within what MySQL handles well.
+func (r *PostRepository) GetPostsByUserIDs(ctx context.Context, userIDs []int) ([]*Post, error) {
+	return inChunks(userIDs, func(ids []int) ([]*Post, error) {
+		return r.getPostsByUserIDs(ctx, ids)
+	})
+}
 
-	placeholders := strings.Repeat("?,", len(userIDs)-1) + "?"
+func (r *PostRepository) getPostsByUserIDs(ctx context.Context, userIDs []int) ([]*Post, error) {
+	placeholders, args := inArgs(userIDs)
 	query := fmt.Sprintf("SELECT id, user_id, title, content, created_at, updated_at FROM posts WHERE user_id IN (%s)", placeholders)
-	
-	args := make([]any, len(userIDs))
-	for i, id := range userIDs {
-		args[i] = id
-	}
-	
-	rows, err := r.db.Query(query, args...)
+
+	rows, err := r.db.Reader(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query posts: %w", err)
 	}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"graphql-backend/database"
+)
+
+// maxTagLength is the longest tag, in characters, that can be attached.
+const maxTagLength = 64
+
+type Tag struct {
+	ID        int       `json:"id" db:"id"`
+	Slug      string    `json:"slug" db:"slug"`
+	Name      string    `json:"name" db:"name"`
+	PostCount int       `json:"post_count" db:"post_count"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// PostTag is a tag together with the post it was loaded for.
+type PostTag struct {
+	PostID int
+	Tag
+}
+
+// InvalidTagError is returned for a tag that is empty after normalization
+// or too long.
+type InvalidTagError struct {
+	Tag string
+}
+
+func (e *InvalidTagError) Error() string {
+	return fmt.Sprintf("invalid tag %q: tags must contain a letter or digit and be at most %d characters", e.Tag, maxTagLength)
+}
+
+// Extensions sets the code of the GraphQL error the resolver returns.
+func (e *InvalidTagError) Extensions() map[string]any {
+	return map[string]any{"code": "INVALID_TAG"}
+}
+
+// NormalizeSlug turns a tag as typed by a user into its slug: lower case,
+// with every run of characters other than letters and digits replaced by a
+// single hyphen, e.g. "Go  Modules!" becomes "go-modules".
+func NormalizeSlug(tag string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(tag) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// tagColumns selects a tag from tags t with its usage count.
+const tagColumns = "t.id, t.slug, t.name, t.created_at, (SELECT COUNT(*) FROM post_tags c WHERE c.tag_id = t.id) AS post_count"
+
+type TagRepository struct {
+	db *database.DB
+}
+
+func NewTagRepository(db *database.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// GetAll returns every tag, most used first.
+func (r *TagRepository) GetAll(ctx context.Context) ([]*Tag, error) {
+	query := "SELECT " + tagColumns + " FROM tags t ORDER BY post_count DESC, t.slug"
+	rows, err := r.db.Reader(ctx).QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []*Tag
+	for rows.Next() {
+		tag := &Tag{}
+		err := rows.Scan(&tag.ID, &tag.Slug, &tag.Name, &tag.CreatedAt, &tag.PostCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// GetBySlug returns the tag with the normalized slug, or nil if there is
+// none.
+func (r *TagRepository) GetBySlug(ctx context.Context, slug string) (*Tag, error) {
+	query := "SELECT " + tagColumns + " FROM tags t WHERE t.slug = ?"
+	row := r.db.Reader(ctx).QueryRowContext(ctx, query, slug)
+
+	tag := &Tag{}
+	err := row.Scan(&tag.ID, &tag.Slug, &tag.Name, &tag.CreatedAt, &tag.PostCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to scan tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// GetTagsByPostIDs returns one PostTag per tag of each of postIDs.
+func (r *TagRepository) GetTagsByPostIDs(ctx context.Context, postIDs []int) ([]*PostTag, error) {
+	return inChunks(postIDs, func(ids []int) ([]*PostTag, error) {
+		return r.getTagsByPostIDs(ctx, ids)
+	})
+}
+
+func (r *TagRepository) getTagsByPostIDs(ctx context.Context, postIDs []int) ([]*PostTag, error) {
+	placeholders, args := inArgs(postIDs)
+	query := fmt.Sprintf("SELECT pt.post_id, %s FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.post_id IN (%s) ORDER BY t.slug", tagColumns, placeholders)
+
+	rows, err := r.db.Reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []*PostTag
+	for rows.Next() {
+		tag := &PostTag{}
+		err := rows.Scan(&tag.PostID, &tag.ID, &tag.Slug, &tag.Name, &tag.CreatedAt, &tag.PostCount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// Attach adds tags to postID, creating the ones that do not exist yet with
+// the tag as typed as their name. Tags the post already has are skipped.
+// Either every tag is attached or, on error, none is.
+func (r *TagRepository) Attach(ctx context.Context, postID int, tags []string) error {
+	ctx = database.MarkWrite(ctx)
+
+	slugs, names, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Writer().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i, slug := range slugs {
+		// 既存のタグなら LAST_INSERT_ID にその ID を返させる
+		query := "INSERT INTO tags (slug, name) VALUES (?, ?) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)"
+		result, err := tx.ExecContext(ctx, query, slug, names[i])
+		if err != nil {
+			return fmt.Errorf("failed to create tag: %w", err)
+		}
+
+		tagID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+
+		// INSERT IGNORE と違い、外部キー違反などのエラーは握りつぶさない
+		query = "INSERT INTO post_tags (post_id, tag_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE post_id = post_id"
+		_, err = tx.ExecContext(ctx, query, postID, tagID)
+		if err != nil {
+			return fmt.Errorf("failed to attach tag: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit tags: %w", err)
+	}
+
+	return nil
+}
+
+// Detach removes tags from postID. Tags the post does not have are ignored;
+// the tags themselves are kept even when no post uses them any more.
+func (r *TagRepository) Detach(ctx context.Context, postID int, tags []string) error {
+	ctx = database.MarkWrite(ctx)
+
+	slugs, _, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+	if len(slugs) == 0 {
+		return nil
+	}
+
+	placeholders, args := inArgs(slugs)
+	query := fmt.Sprintf("DELETE pt FROM post_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.post_id = ? AND t.slug IN (%s)", placeholders)
+	_, err = r.db.Writer().ExecContext(ctx, query, append([]any{postID}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to detach tags: %w", err)
+	}
+
+	return nil
+}
+
+// normalizeTags returns the distinct slugs of tags and, for each, the first
+// tag (trimmed) that produced it.
+func normalizeTags(tags []string) ([]string, []string, error) {
+	var slugs, names []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		name := strings.TrimSpace(tag)
+		slug := NormalizeSlug(name)
+		if slug == "" || utf8.RuneCountInString(name) > maxTagLength {
+			return nil, nil, &InvalidTagError{Tag: tag}
+		}
+		if seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		slugs = append(slugs, slug)
+		names = append(names, name)
+	}
+	return slugs, names, nil
+}
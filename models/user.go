@@ -1,9 +1,13 @@
 package models
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
+
+	"graphql-backend/cache"
+	"graphql-backend/database"
 )
 
 type User struct {
@@ -15,16 +19,19 @@ type User struct {
 }
 
 type UserRepository struct {
-	db *sql.DB
+	db    *database.DB
+	cache cache.Store
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{db: db}
+// NewUserRepository returns a repository that invalidates the entries of store
+// (which may be nil) when it writes.
+func NewUserRepository(db *database.DB, store cache.Store) *UserRepository {
+	return &UserRepository{db: db, cache: store}
 }
 
-func (r *UserRepository) GetAll() ([]*User, error) {
+func (r *UserRepository) GetAll(ctx context.Context) ([]*User, error) {
 	query := "SELECT id, name, email, created_at, updated_at FROM users ORDER BY id"
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Reader(ctx).QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query users: %w", err)
 	}
@@ -43,9 +50,9 @@ func (r *UserRepository) GetAll() ([]*User, error) {
 	return users, nil
 }
 
-func (r *UserRepository) GetByID(id int) (*User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id int) (*User, error) {
 	query := "SELECT id, name, email, created_at, updated_at FROM users WHERE id = ?"
-	row := r.db.QueryRow(query, id)
+	row := r.db.Reader(ctx).QueryRowContext(ctx, query, id)
 
 	user := &User{}
 	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt)
@@ -59,9 +66,42 @@ func (r *UserRepository) GetByID(id int) (*User, error) {
 	return user, nil
 }
 
-func (r *UserRepository) Create(name, email string) (*User, error) {
+// GetByIDs returns the users with the given IDs in no particular order;
+// unknown IDs are left out.
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []int) ([]*User, error) {
+	return inChunks(ids, func(ids []int) ([]*User, error) {
+		return r.getByIDs(ctx, ids)
+	})
+}
+
+func (r *UserRepository) getByIDs(ctx context.Context, ids []int) ([]*User, error) {
+	placeholders, args := inArgs(ids)
+	query := fmt.Sprintf("SELECT id, name, email, created_at, updated_at FROM users WHERE id IN (%s)", placeholders)
+
+	rows, err := r.db.Reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*User
+	for rows.Next() {
+		user := &User{}
+		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) Create(ctx context.Context, name, email string) (*User, error) {
+	ctx = database.MarkWrite(ctx)
+
 	query := "INSERT INTO users (name, email) VALUES (?, ?)"
-	result, err := r.db.Exec(query, name, email)
+	result, err := r.db.Writer().ExecContext(ctx, query, name, email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -71,11 +111,13 @@ func (r *UserRepository) Create(name, email string) (*User, error) {
 		return nil, fmt.Errorf("failed to get last insert id: %w", err)
 	}
 
-	return r.GetByID(int(id))
+	return r.GetByID(ctx, int(id))
 }
 
-func (r *UserRepository) Update(id int, name, email *string) (*User, error) {
-	user, err := r.GetByID(id)
+func (r *UserRepository) Update(ctx context.Context, id int, name, email *string) (*User, error) {
+	ctx = database.MarkWrite(ctx)
+
+	user, err := r.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -88,17 +130,20 @@ func (r *UserRepository) Update(id int, name, email *string) (*User, error) {
 	}
 
 	query := "UPDATE users SET name = ?, email = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?"
-	_, err = r.db.Exec(query, user.Name, user.Email, id)
+	_, err = r.db.Writer().ExecContext(ctx, query, user.Name, user.Email, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
+	invalidate(ctx, r.db, r.cache, UserCacheKey(id))
 
-	return r.GetByID(id)
+	return r.GetByID(ctx, id)
 }
 
-func (r *UserRepository) Delete(id int) error {
+func (r *UserRepository) Delete(ctx context.Context, id int) error {
+	ctx = database.MarkWrite(ctx)
+
 	query := "DELETE FROM users WHERE id = ?"
-	result, err := r.db.Exec(query, id)
+	result, err := r.db.Writer().ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -111,6 +156,8 @@ func (r *UserRepository) Delete(id int) error {
 	if rowsAffected == 0 {
 		return fmt.Errorf("user not found")
 	}
+	// ユーザーに紐づく投稿一覧のキャッシュも一緒に捨てる
+	invalidate(ctx, r.db, r.cache, UserCacheKey(id), PostsByUserCacheKey(id))
 
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,51 @@
+package seeds
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+var words = []string{
+	"graph", "query", "loader", "batch", "schema", "resolver", "mutation",
+	"field", "cache", "index", "latency", "request", "service", "cursor",
+	"payload", "network", "client", "server", "table", "record",
+}
+
+// Fake generates a load-testing set of users with postsPerUser posts each.
+// Names, emails and content are derived from the user number so the same
+// arguments always produce the same rows and re-seeding stays idempotent.
+func Fake(users, postsPerUser int) Set {
+	set := Set{
+		Name:  fmt.Sprintf("fake_%dx%d", users, postsPerUser),
+		Users: make([]UserSeed, users),
+	}
+
+	for i := range users {
+		rng := rand.New(rand.NewSource(int64(i + 1)))
+
+		posts := make([]PostSeed, postsPerUser)
+		for j := range posts {
+			posts[j] = PostSeed{
+				Title:   fmt.Sprintf("Fake post %d-%d", i+1, j+1),
+				Content: sentence(rng, 12),
+			}
+		}
+
+		set.Users[i] = UserSeed{
+			Name:  fmt.Sprintf("Fake User %d", i+1),
+			Email: fmt.Sprintf("fake-user-%d@example.test", i+1),
+			Posts: posts,
+		}
+	}
+
+	return set
+}
+
+func sentence(rng *rand.Rand, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = words[rng.Intn(len(words))]
+	}
+	return strings.ToUpper(parts[0][:1]) + strings.Join(parts, " ")[1:] + "."
+}
@@ -0,0 +1,213 @@
+package seeds
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// batchSize caps the number of rows per multi-row INSERT / IN (...) query.
+const batchSize = 500
+
+// Set is a named group of seed data that is only loaded in the listed
+// environments.
+type Set struct {
+	Name         string
+	Environments []string
+	Users        []UserSeed
+}
+
+// UserSeed is a user identified by its email, together with its posts.
+type UserSeed struct {
+	Name  string
+	Email string
+	Posts []PostSeed
+}
+
+// PostSeed is a post identified by its title within the owning user.
+type PostSeed struct {
+	Title   string
+	Content string
+}
+
+// AllowedIn reports whether the set may be loaded in env.
+func (s Set) AllowedIn(env string) bool {
+	for _, e := range s.Environments {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
+
+type Seeder struct {
+	db *sql.DB
+}
+
+func NewSeeder(db *sql.DB) *Seeder {
+	return &Seeder{db: db}
+}
+
+// Run loads the set in a single transaction. Seeding is idempotent: users are
+// upserted by email and posts by (user, title), so running a set twice leaves
+// the database unchanged.
+func (s *Seeder) Run(ctx context.Context, set Set) error {
+	log.Printf("Seeding %s (%d users)", set.Name, len(set.Users))
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := seedUsers(ctx, tx, set.Users); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to seed %s: %w", set.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit seed %s: %w", set.Name, err)
+	}
+
+	log.Printf("Seed %s loaded successfully", set.Name)
+	return nil
+}
+
+func seedUsers(ctx context.Context, tx *sql.Tx, users []UserSeed) error {
+	for start := 0; start < len(users); start += batchSize {
+		chunk := users[start:min(start+batchSize, len(users))]
+
+		if err := upsertUsers(ctx, tx, chunk); err != nil {
+			return err
+		}
+
+		ids, err := userIDsByEmail(ctx, tx, chunk)
+		if err != nil {
+			return err
+		}
+
+		if err := upsertPosts(ctx, tx, chunk, ids); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func upsertUsers(ctx context.Context, tx *sql.Tx, users []UserSeed) error {
+	placeholders := strings.Repeat("(?, ?),", len(users)-1) + "(?, ?)"
+	query := fmt.Sprintf("INSERT INTO users (name, email) VALUES %s ON DUPLICATE KEY UPDATE name = VALUES(name)", placeholders)
+
+	args := make([]any, 0, len(users)*2)
+	for _, u := range users {
+		args = append(args, u.Name, u.Email)
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to upsert users: %w", err)
+	}
+	return nil
+}
+
+func userIDsByEmail(ctx context.Context, tx *sql.Tx, users []UserSeed) (map[string]int, error) {
+	placeholders := strings.Repeat("?,", len(users)-1) + "?"
+	query := fmt.Sprintf("SELECT id, email FROM users WHERE email IN (%s)", placeholders)
+
+	args := make([]any, len(users))
+	for i, u := range users {
+		args[i] = u.Email
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query seeded users: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int, len(users))
+	for rows.Next() {
+		var id int
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		ids[email] = id
+	}
+	return ids, rows.Err()
+}
+
+type postKey struct {
+	userID int
+	title  string
+}
+
+type existingPost struct {
+	id      int
+	content string
+}
+
+func upsertPosts(ctx context.Context, tx *sql.Tx, users []UserSeed, ids map[string]int) error {
+	userIDs := make([]any, 0, len(ids))
+	for _, id := range ids {
+		userIDs = append(userIDs, id)
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	placeholders := strings.Repeat("?,", len(userIDs)-1) + "?"
+	query := fmt.Sprintf("SELECT id, user_id, title, content FROM posts WHERE user_id IN (%s)", placeholders)
+
+	rows, err := tx.QueryContext(ctx, query, userIDs...)
+	if err != nil {
+		return fmt.Errorf("failed to query posts: %w", err)
+	}
+	existing := make(map[postKey]existingPost)
+	for rows.Next() {
+		var p existingPost
+		var key postKey
+		if err := rows.Scan(&p.id, &key.userID, &key.title, &p.content); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan post: %w", err)
+		}
+		existing[key] = p
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read posts: %w", err)
+	}
+
+	var inserts []any
+	for _, u := range users {
+		userID, ok := ids[u.Email]
+		if !ok {
+			return fmt.Errorf("seeded user %s not found", u.Email)
+		}
+		for _, p := range u.Posts {
+			current, ok := existing[postKey{userID: userID, title: p.Title}]
+			if !ok {
+				inserts = append(inserts, userID, p.Title, p.Content)
+				continue
+			}
+			if current.content == p.Content {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, "UPDATE posts SET content = ? WHERE id = ?", p.Content, current.id); err != nil {
+				return fmt.Errorf("failed to update post: %w", err)
+			}
+		}
+	}
+
+	const columns = 3
+	for start := 0; start < len(inserts); start += batchSize * columns {
+		chunk := inserts[start:min(start+batchSize*columns, len(inserts))]
+		n := len(chunk) / columns
+		placeholders := strings.Repeat("(?, ?, ?),", n-1) + "(?, ?, ?)"
+		query := fmt.Sprintf("INSERT INTO posts (user_id, title, content) VALUES %s", placeholders)
+		if _, err := tx.ExecContext(ctx, query, chunk...); err != nil {
+			return fmt.Errorf("failed to insert posts: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package seeds
+
+import "fmt"
+
+// sets is the registry of seed sets, loaded in the order listed here.
+var sets = []Set{
+	{
+		Name:         "demo",
+		Environments: []string{"development", "test"},
+		Users: []UserSeed{
+			{
+				Name:  "John Doe",
+				Email: "john@example.com",
+				Posts: []PostSeed{
+					{Title: "Post 1", Content: "Content 1"},
+					{Title: "Post 4", Content: "Content 4"},
+					{Title: "Post 7", Content: "Content 7"},
+				},
+			},
+			{
+				Name:  "Jane Smith",
+				Email: "jane@example.com",
+				Posts: []PostSeed{
+					{Title: "Post 2", Content: "Content 2"},
+					{Title: "Post 5", Content: "Content 5"},
+					{Title: "Post 8", Content: "Content 8"},
+				},
+			},
+			{
+				Name:  "Bob Johnson",
+				Email: "bob@example.com",
+				Posts: []PostSeed{
+					{Title: "Post 3", Content: "Content 3"},
+					{Title: "Post 6", Content: "Content 6"},
+					{Title: "Post 9", Content: "Content 9"},
+				},
+			},
+		},
+	},
+}
+
+// Sets returns every registered seed set.
+func Sets() []Set {
+	return sets
+}
+
+// ForEnvironment returns the sets allowed in env, optionally narrowed to a
+// single set by name.
+func ForEnvironment(env, name string) ([]Set, error) {
+	var result []Set
+	for _, set := range sets {
+		if name != "" && set.Name != name {
+			continue
+		}
+		if !set.AllowedIn(env) {
+			if name != "" {
+				return nil, fmt.Errorf("seed set %s is not allowed in %s", name, env)
+			}
+			continue
+		}
+		result = append(result, set)
+	}
+
+	if name != "" && len(result) == 0 {
+		return nil, fmt.Errorf("seed set %s not found", name)
+	}
+	return result, nil
+}
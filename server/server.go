@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"graphql-backend/config"
+)
+
+// Run serves handler until ctx is cancelled, then shuts down gracefully: the
+// listener is closed, in-flight requests are drained and websocket
+// subscriptions are closed, all within cfg.ShutdownTimeout.
+func Run(ctx context.Context, cfg config.ServerConfig, handler http.Handler) error {
+	websockets := newWebsocketTracker()
+
+	srv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           websockets.Middleware(handler),
+		ReadTimeout:       cfg.ReadTimeout.Duration,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout.Duration,
+		WriteTimeout:      cfg.WriteTimeout.Duration,
+		IdleTimeout:       cfg.IdleTimeout.Duration,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("server stopped: %w", err)
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down, draining requests for up to %s", cfg.ShutdownTimeout.Duration)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+	defer cancel()
+
+	// Shutdown は hijack された websocket を待たないので別途閉じて待つ
+	wsErr := make(chan error, 1)
+	go func() {
+		wsErr <- websockets.Shutdown(shutdownCtx)
+	}()
+
+	err := srv.Shutdown(shutdownCtx)
+	if wErr := <-wsErr; err == nil {
+		err = wErr
+	}
+	if err != nil {
+		srv.Close()
+		return fmt.Errorf("graceful shutdown incomplete: %w", err)
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	log.Println("Server stopped gracefully")
+	return nil
+}
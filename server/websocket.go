@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pollInterval is how often Shutdown checks for the remaining websockets.
+const pollInterval = 50 * time.Millisecond
+
+// websocketTracker keeps track of upgraded connections so they can be closed
+// and waited for on shutdown.
+type websocketTracker struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	active int
+}
+
+func newWebsocketTracker() *websocketTracker {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &websocketTracker{ctx: ctx, cancel: cancel}
+}
+
+func (t *websocketTracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isWebsocket(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		t.mu.Lock()
+		t.active++
+		t.mu.Unlock()
+		defer func() {
+			t.mu.Lock()
+			t.active--
+			t.mu.Unlock()
+		}()
+
+		// 購読は長時間続くので、サーバーの read/write タイムアウトを外す
+		rc := http.NewResponseController(w)
+		rc.SetReadDeadline(time.Time{})
+		rc.SetWriteDeadline(time.Time{})
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		stop := context.AfterFunc(t.ctx, cancel)
+		defer stop()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Shutdown cancels every subscription and waits until their handlers return
+// or ctx expires.
+func (t *websocketTracker) Shutdown(ctx context.Context) error {
+	t.cancel()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		t.mu.Lock()
+		active := t.active
+		t.mu.Unlock()
+		if active == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d websocket connections still open: %w", active, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func isWebsocket(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
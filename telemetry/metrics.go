@@ -0,0 +1,55 @@
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics are registered with the default Prometheus registry and served by
+// MetricsHandler.
+var (
+	OperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "graphql_operation_duration_seconds",
+		Help:    "GraphQL operation latency by operation name and type.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "type"})
+
+	OperationErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "graphql_errors_total",
+		Help: "GraphQL errors by extensions.code.",
+	}, []string{"code"})
+
+	LoaderBatchSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dataloader_batch_size",
+		Help:    "Keys per DataLoader batch.",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"loader"})
+
+	// LoaderCacheLookups counts per-request cache lookups with result "hit"
+	// or "miss"; the hit ratio is hit / (hit + miss).
+	LoaderCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "dataloader_cache_lookups_total",
+		Help: "DataLoader cache lookups by loader and result.",
+	}, []string{"loader", "result"})
+
+	// SharedCacheLookups counts lookups in the cross-request cache behind
+	// the loaders, with result "hit" or "miss".
+	SharedCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shared_cache_lookups_total",
+		Help: "Shared cache lookups by loader and result.",
+	}, []string{"loader", "result"})
+
+	SQLDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sql_query_duration_seconds",
+		Help:    "SQL statement latency by repository method.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method"})
+)
+
+// MetricsHandler serves the default registry in the Prometheus text format.
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}